@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"sportsagent/internal/auth"
+)
+
+const authUsage = "usage: sportsagent auth hash-key [key | -]"
+
+func runAuth(args []string) error {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) == 0 || rest[0] != "hash-key" || len(rest) > 2 {
+		return errors.New(authUsage)
+	}
+
+	var key string
+	switch {
+	case len(rest) == 1:
+		// Generate a key so it never has to be typed into a shell
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		key = "sa_" + base64.RawURLEncoding.EncodeToString(b)
+		fmt.Printf("key:  %s\n", key)
+	case rest[1] == "-":
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read key from stdin: %w", err)
+		}
+		key = strings.TrimSpace(line)
+	default:
+		key = rest[1]
+	}
+
+	fmt.Printf("hash: %s\n", auth.HashKey(key))
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/services"
+)
+
+const chatHelp = `Commands:
+  /tools          list the tools available to the model
+  /reset          clear the conversation history
+  /model [name]   show or change the model for this session
+  /prompt [name]  show or change the system prompt template
+  /trace          toggle full tool results and completion details
+  /help           show this help
+  /quit           leave the chat
+`
+
+const resultPreviewLen = 120
+
+// chatSession is an interactive REPL driving the agent in-process
+type chatSession struct {
+	agent *services.AgentService
+	conv  *services.Conversation
+	// model is set by /model; empty leaves the model and routing to the agent
+	model string
+	// prompt is the system prompt template; empty uses the configured default
+	prompt string
+	trace  bool
+	out    io.Writer
+	// pending is a query waiting for the user to approve its tool calls
+	pending *services.PendingApproval
+}
+
+func runChat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	trace := fs.Bool("trace", false, "start with tracing enabled")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	agent := services.NewAgentService(cfg)
+	session := &chatSession{
+		agent:  agent,
+		conv:   services.NewConversation(),
+		prompt: cfg.Prompts.Default,
+		trace:  *trace,
+		out:    os.Stdout,
+	}
+
+	return session.loop(os.Stdin)
+}
+
+// modelName describes the session's model. Until /model picks one, each query is left to
+// the agent's default model and routing.
+func (c *chatSession) modelName() string {
+	if c.model == "" {
+		return c.agent.Model() + " (default)"
+	}
+	return c.model
+}
+
+func (c *chatSession) loop(in io.Reader) error {
+	fmt.Fprintf(c.out, "sportsagent chat (model %s) - /help for commands\n", c.modelName())
+
+	scanner := bufio.NewScanner(in)
+	for {
+		if c.pending != nil {
+			fmt.Fprint(c.out, "approve? [y/N] ")
+		} else {
+			fmt.Fprint(c.out, "> ")
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case c.pending != nil:
+			c.decide(line)
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "/"):
+			if quit := c.command(line); quit {
+				return nil
+			}
+		default:
+			c.ask(line)
+		}
+	}
+}
+
+// command handles a slash command and reports whether the session should end
+func (c *chatSession) command(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "/quit", "/exit":
+		return true
+	case "/help":
+		fmt.Fprint(c.out, chatHelp)
+	case "/reset":
+		c.conv.Reset()
+		fmt.Fprintln(c.out, "conversation cleared")
+	case "/model":
+		if len(fields) > 1 {
+			c.model = fields[1]
+		}
+		fmt.Fprintf(c.out, "model: %s\n", c.modelName())
+	case "/prompt":
+		if len(fields) > 1 && !slices.Contains(c.agent.Prompts(), fields[1]) {
+			fmt.Fprintf(c.out, "unknown prompt %s\n", fields[1])
+		} else if len(fields) > 1 {
+			c.prompt = fields[1]
+		}
+		fmt.Fprintf(c.out, "prompt: %s (available: %s)\n", c.prompt, strings.Join(c.agent.Prompts(), ", "))
+	case "/trace":
+		c.trace = !c.trace
+		fmt.Fprintf(c.out, "trace: %t\n", c.trace)
+	case "/tools":
+		for _, tool := range c.agent.Tools() {
+			if fn := tool.GetFunction(); fn != nil {
+				fmt.Fprintf(c.out, "  %-32s %s\n", fn.Name, fn.Description.Value)
+			}
+		}
+	default:
+		fmt.Fprintf(c.out, "unknown command %s - /help for commands\n", fields[0])
+	}
+	return false
+}
+
+// ask sends a question to the agent, streaming the answer and tool activity to the terminal.
+// Ctrl-C cancels the current question without leaving the chat.
+func (c *chatSession) ask(query string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := c.agent.Run(ctx, c.conv, services.Request{Query: query, Model: c.model, Prompt: c.prompt, Hooks: c.hooks()})
+	c.finish(result, err)
+}
+
+// decide answers a pending approval; anything other than y or yes rejects the calls
+func (c *chatSession) decide(answer string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	id := c.pending.ID
+	c.pending = nil
+	approved := strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+
+	result, err := c.agent.Resume(ctx, id, services.AnonymousCaller, services.Decision{Approved: approved}, c.hooks())
+	c.finish(result, err)
+}
+
+// finish reports the outcome of a turn, holding it if tool calls need approval
+func (c *chatSession) finish(result *services.Result, err error) {
+	if err != nil {
+		fmt.Fprintf(c.out, "\nerror: %v\n", err)
+		return
+	}
+	if result.Pending != nil {
+		c.pending = result.Pending
+		fmt.Fprintln(c.out, "the agent wants to make changes:")
+		for _, call := range result.Pending.ToolCalls {
+			if call.RequiresApproval {
+				fmt.Fprintf(c.out, "  %s %s %s(%s)\n", call.Method, call.Path, call.Tool, call.Arguments)
+			}
+		}
+		return
+	}
+	fmt.Fprintln(c.out)
+	if c.trace {
+		fmt.Fprintf(c.out, "  [model %s, %s]\n", result.Model, result.Route)
+		for _, source := range result.Sources {
+			fmt.Fprintf(c.out, "  [%s] %s %s %s sha256:%s\n", source.Label, source.Tool, source.Method, source.URL, source.SHA256[:12])
+		}
+	}
+}
+
+// hooks streams the answer and tool activity to the terminal
+func (c *chatSession) hooks() *services.Hooks {
+	hooks := &services.Hooks{
+		OnToolCall: func(name, arguments string) {
+			fmt.Fprintf(c.out, "  → %s(%s)\n", name, arguments)
+		},
+		OnToolResult: func(name, result string) {
+			if c.trace {
+				fmt.Fprintf(c.out, "  ← %s\n%s\n", name, result)
+				return
+			}
+			fmt.Fprintf(c.out, "  ← %s\n", previewResult(result))
+		},
+		OnContent: func(delta string) {
+			fmt.Fprint(c.out, delta)
+		},
+	}
+	if c.trace {
+		hooks.OnCompletion = func(iteration int, finishReason string) {
+			fmt.Fprintf(c.out, "  [iteration %d: %s]\n", iteration, finishReason)
+		}
+	}
+	return hooks
+}
+
+// previewResult collapses a tool result onto a single short line
+func previewResult(result string) string {
+	preview := []rune(strings.Join(strings.Fields(result), " "))
+	if len(preview) > resultPreviewLen {
+		preview = append(preview[:resultPreviewLen], '…')
+	}
+	return fmt.Sprintf("%s (%d bytes)", string(preview), len(result))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"sportsagent/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+func runConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) != 1 || rest[0] != "print" {
+		return errors.New("usage: sportsagent config print")
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(cfg.Redacted())
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/services"
+)
+
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query := strings.TrimSpace(strings.Join(rest, " "))
+	if query == "" {
+		return errors.New(`usage: sportsagent query "<question>"`)
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	response, err := services.NewAgentService(cfg).ProcessQuery(context.Background(), query)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(response)
+	return nil
+}
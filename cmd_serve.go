@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/handlers"
+	"sportsagent/internal/logging"
+	"sportsagent/internal/telemetry"
+	"sportsagent/internal/version"
+)
+
+// tracingFlushTimeout bounds how long buffered spans may take to export on exit
+const tracingFlushTimeout = 5 * time.Second
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	shutdown, err := telemetry.Setup(context.Background(), cfg.Telemetry)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	if cfg.OpenAI.APIKey == "" {
+		slog.Warn("no OpenAI API key configured (openai.apiKey, $OPENAI_API_KEY)")
+	}
+
+	healthHandler := handlers.NewHealthHandler(newReadinessChecker(cfg))
+	mux, err := setupServer(cfg, healthHandler)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           logging.Middleware(mux),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	ln, err := net.Listen("tcp", cfg.Server.Addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("starting server", "version", version.BuildVersion, "addr", cfg.Server.Addr)
+	return serveUntilDone(ctx, srv, ln, healthHandler, cfg.Server.ShutdownTimeout)
+}
+
+// serveUntilDone serves on ln until ctx is cancelled, then marks the instance as draining
+// and lets in-flight requests finish for up to drainTimeout. Requests still running after
+// the deadline have their contexts cancelled, which aborts outstanding model and tool calls.
+func serveUntilDone(ctx context.Context, srv *http.Server, ln net.Listener, healthHandler *handlers.HealthHandler, drainTimeout time.Duration) error {
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv.BaseContext = func(net.Listener) context.Context { return requestCtx }
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown requested, draining in-flight requests", "timeout", drainTimeout.String())
+	healthHandler.SetReady(false)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("drain deadline exceeded, cancelling in-flight requests", "error", err)
+		cancelRequests()
+		srv.Close()
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/services"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func runSpec(args []string) error {
+	fs := flag.NewFlagSet("spec", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) != 1 || rest[0] != "dump" {
+		return errors.New("usage: sportsagent spec dump")
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	functions := []openai.FunctionDefinitionParam{}
+	for _, tool := range services.NewAgentService(cfg).Tools() {
+		if fn := tool.GetFunction(); fn != nil {
+			functions = append(functions, *fn)
+		}
+	}
+
+	return printJSON(functions)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/services"
+	"sportsagent/internal/tools"
+)
+
+const toolsUsage = "usage: sportsagent tools [-dry-run] list | show <name> | invoke <name> [json-args]"
+
+func runTools(args []string) error {
+	fs := flag.NewFlagSet("tools", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "with invoke, print the constructed request without sending it")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return errors.New(toolsUsage)
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	agent := services.NewAgentService(cfg)
+
+	switch rest[0] {
+	case "list":
+		return listTools(agent)
+	case "show":
+		if len(rest) != 2 {
+			return errors.New("usage: sportsagent tools show <name>")
+		}
+		return showTool(agent, rest[1])
+	case "invoke":
+		if len(rest) < 2 || len(rest) > 3 {
+			return errors.New("usage: sportsagent tools invoke <name> [json-args]")
+		}
+		rawArgs := "{}"
+		if len(rest) == 3 {
+			rawArgs = rest[2]
+		}
+		return invokeTool(agent, rest[1], rawArgs, *dryRun)
+	default:
+		return errors.New(toolsUsage)
+	}
+}
+
+func listTools(agent *services.AgentService) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSERVICE\tMETHOD\tPATH\tDESCRIPTION")
+	for _, tool := range agent.Tools() {
+		fn := tool.GetFunction()
+		if fn == nil {
+			continue
+		}
+		metadata, _ := tools.GetToolMetadata(fn.Name)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", fn.Name, metadata.Service, metadata.Method, metadata.Path, fn.Description.Value)
+	}
+	return w.Flush()
+}
+
+func showTool(agent *services.AgentService, name string) error {
+	fn, ok := tools.FindTool(agent.Tools(), name)
+	if !ok {
+		return fmt.Errorf("unknown tool %s", name)
+	}
+	metadata, _ := tools.GetToolMetadata(name)
+
+	return printJSON(struct {
+		Function any                `json:"function"`
+		Metadata tools.ToolMetadata `json:"metadata"`
+	}{Function: fn, Metadata: metadata})
+}
+
+func invokeTool(agent *services.AgentService, name, rawArgs string, dryRun bool) error {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(rawArgs), &args); err != nil {
+		return fmt.Errorf("invalid JSON arguments: %w", err)
+	}
+
+	invocation, err := agent.InspectToolCall(context.Background(), name, args, dryRun)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(invocation)
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
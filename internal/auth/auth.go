@@ -0,0 +1,192 @@
+// Package auth authenticates API callers with static API keys or JWT bearer tokens and
+// rate limits them per client.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sportsagent/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodAnonymous = "anonymous"
+
+	// ScopeAll grants every tool
+	ScopeAll = "*"
+)
+
+var (
+	// ErrMissingCredentials means the request carried neither an API key nor a bearer token
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials means the presented key or token was not accepted
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Client is the authenticated caller of a request
+type Client struct {
+	// ID is prefixed with how the client authenticated (key:, jwt: or ip:), so an API key
+	// id and a JWT subject never share a rate limit, budget, sources or approvals
+	ID     string
+	Method string
+	// Scopes name the tool sets the client may use; see config.AuthConfig.Scopes
+	Scopes []string
+}
+
+type clientKey struct{}
+
+// WithClient returns a context carrying client
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFrom returns the client attached by the middleware, if any
+func ClientFrom(ctx context.Context) (*Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(*Client)
+	return client, ok
+}
+
+// HashKey returns the config representation of an API key
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Authenticator checks API keys and JWTs from the configuration
+type Authenticator struct {
+	keys        []apiKey
+	jwks        *keySet
+	issuer      string
+	audience    string
+	clientClaim string
+	scopesClaim string
+}
+
+type apiKey struct {
+	id     string
+	digest []byte
+	scopes []string
+}
+
+// NewAuthenticator loads the configured keys and JWKS file
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		issuer:      cfg.JWT.Issuer,
+		audience:    cfg.JWT.Audience,
+		clientClaim: cfg.JWT.ClientClaim,
+		scopesClaim: cfg.JWT.ScopesClaim,
+	}
+
+	for _, key := range cfg.APIKeys {
+		digest, err := hex.DecodeString(strings.TrimPrefix(key.Hash, "sha256:"))
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", key.ID, err)
+		}
+		scopes := key.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{ScopeAll}
+		}
+		a.keys = append(a.keys, apiKey{id: key.ID, digest: digest, scopes: scopes})
+	}
+
+	if cfg.JWT.JWKSFile != "" {
+		jwks, err := loadKeySet(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether any credentials are configured. Without them every request
+// is served as an anonymous client.
+func (a *Authenticator) Enabled() bool {
+	return len(a.keys) > 0 || a.jwks != nil
+}
+
+// Authenticate identifies the caller from X-API-Key or an Authorization bearer token.
+// Bearer values shaped like a JWT are validated as tokens, anything else as an API key.
+func (a *Authenticator) Authenticate(r *http.Request) (*Client, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.authenticateKey(key)
+	}
+
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || bearer == "" {
+		return nil, ErrMissingCredentials
+	}
+	if strings.Count(bearer, ".") == 2 && a.jwks != nil {
+		return a.authenticateJWT(bearer)
+	}
+	return a.authenticateKey(bearer)
+}
+
+func (a *Authenticator) authenticateKey(key string) (*Client, error) {
+	sum := sha256.Sum256([]byte(key))
+	// Compare against every key so timing does not reveal which prefix matched
+	var match *apiKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].digest) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Client{ID: "key:" + match.id, Method: MethodAPIKey, Scopes: match.scopes}, nil
+}
+
+func (a *Authenticator) authenticateJWT(raw string) (*Client, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(a.jwks.algorithms()),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		opts = append(opts, jwt.WithAudience(a.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, a.jwks.keyfunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	id, _ := claims[a.clientClaim].(string)
+	if id == "" {
+		return nil, fmt.Errorf("%w: token has no %q claim", ErrInvalidCredentials, a.clientClaim)
+	}
+	return &Client{ID: "jwt:" + id, Method: MethodJWT, Scopes: scopesFromClaim(claims[a.scopesClaim])}, nil
+}
+
+// scopesFromClaim accepts the space-separated "scope" form (RFC 8693) as well as the
+// array form some identity providers use in "scp"
+func scopesFromClaim(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		scopes := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"sportsagent/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeJWKS generates an Ed25519 key, writes its public half as a JWKS file and returns
+// the private key for signing test tokens
+func writeJWKS(t *testing.T, kid string) (string, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	doc := `{"keys":[{"kty":"OKP","crv":"Ed25519","use":"sig","kid":"` + kid + `","x":"` +
+		base64.RawURLEncoding.EncodeToString(pub) + `"}]}`
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatalf("write JWKS: %v", err)
+	}
+	return path, priv
+}
+
+func signToken(t *testing.T, key ed25519.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, err := NewAuthenticator(config.AuthConfig{
+		APIKeys: []config.APIKeyConfig{{ID: "widget", Hash: HashKey("sa_widget")}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		wantID  string
+		wantErr error
+	}{
+		{"x-api-key", "X-API-Key", "sa_widget", "key:widget", nil},
+		{"bearer", "Authorization", "Bearer sa_widget", "key:widget", nil},
+		{"wrong key", "X-API-Key", "sa_other", "", ErrInvalidCredentials},
+		{"missing", "", "", "", ErrMissingCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			client, err := a.Authenticate(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (client.ID != tt.wantID || client.Method != MethodAPIKey) {
+				t.Fatalf("unexpected client %+v", client)
+			}
+		})
+	}
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	path, key := writeJWKS(t, "k1")
+	a, err := NewAuthenticator(config.AuthConfig{
+		JWT: config.JWTConfig{JWKSFile: path, Issuer: "https://idp.example", Audience: "sportsagent", ClientClaim: "sub"},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator returned error: %v", err)
+	}
+
+	valid := jwt.MapClaims{
+		"iss": "https://idp.example",
+		"aud": "sportsagent",
+		"sub": "trader-desk",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	with := func(k string, v any) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for name, value := range valid {
+			claims[name] = value
+		}
+		claims[k] = v
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signToken(t, key, "k1", valid), true},
+		{"expired", signToken(t, key, "k1", with("exp", time.Now().Add(-time.Minute).Unix())), false},
+		{"wrong audience", signToken(t, key, "k1", with("aud", "someone-else")), false},
+		{"wrong issuer", signToken(t, key, "k1", with("iss", "https://evil.example")), false},
+		{"unknown kid", signToken(t, key, "k2", valid), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			client, err := a.Authenticate(req)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("expected token to be accepted, got %v", err)
+				}
+				if client.ID != "jwt:trader-desk" || client.Method != MethodJWT {
+					t.Fatalf("unexpected client %+v", client)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("expected invalid credentials, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+	now := time.Unix(1_700_000_000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("request %d should fit in the burst", i+1)
+		}
+	}
+	ok, retryAfter := limiter.Allow("a")
+	if ok || retryAfter != time.Second {
+		t.Fatalf("expected rejection with 1s retry, got ok=%v retryAfter=%s", ok, retryAfter)
+	}
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Fatal("other clients have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Fatal("expected a token after refilling for 1s")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(config.AuthConfig{
+		APIKeys: []config.APIKeyConfig{{ID: "widget", Hash: HashKey("sa_widget")}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator returned error: %v", err)
+	}
+	limiter := NewRateLimiter(config.RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+
+	var seen *Client
+	handler := Middleware(a, limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = ClientFrom(r.Context())
+	}))
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with challenge, got %d", w.Code)
+	}
+
+	if w := serve("sa_widget"); w.Code != http.StatusOK || seen == nil || seen.ID != "key:widget" {
+		t.Fatalf("expected authenticated request, got %d with client %+v", w.Code, seen)
+	}
+
+	w := serve("sa_widget")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds < 1 {
+		t.Fatalf("expected Retry-After in seconds, got %q", w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwk holds the public members of a JSON Web Key (RFC 7517) this package understands
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet is the set of verification keys loaded from a JWKS file
+type keySet struct {
+	keys map[string]crypto.PublicKey
+	algs map[string]bool
+}
+
+func loadKeySet(path string) (*keySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS file: %w", err)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse JWKS file %s: %w", path, err)
+	}
+
+	set := &keySet{keys: map[string]crypto.PublicKey{}, algs: map[string]bool{}}
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, algs, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (kid %q): %w", i, k.Kid, err)
+		}
+		set.keys[k.Kid] = key
+		for _, alg := range algs {
+			set.algs[alg] = true
+		}
+	}
+	if len(set.keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s contains no signing keys", path)
+	}
+	return set, nil
+}
+
+// publicKey decodes the key and lists the algorithms it may verify
+func (k jwk) publicKey() (crypto.PublicKey, []string, error) {
+	restrict := func(algs ...string) []string {
+		if k.Alg != "" {
+			return []string{k.Alg}
+		}
+		return algs
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, nil, fmt.Errorf("exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, restrict("RS256", "RS384", "RS512", "PS256", "PS384", "PS512"), nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var alg string
+		switch k.Crv {
+		case "P-256":
+			curve, alg = elliptic.P256(), "ES256"
+		case "P-384":
+			curve, alg = elliptic.P384(), "ES384"
+		case "P-521":
+			curve, alg = elliptic.P521(), "ES512"
+		default:
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, restrict(alg), nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), restrict("EdDSA"), nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (s *keySet) algorithms() []string {
+	algs := make([]string, 0, len(s.algs))
+	for alg := range s.algs {
+		algs = append(algs, alg)
+	}
+	return algs
+}
+
+// keyfunc selects the verification key by the token's kid header. Tokens without a
+// kid are accepted only when the set holds a single key.
+func (s *keySet) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
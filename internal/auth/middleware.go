@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"sportsagent/internal/metrics"
+)
+
+// Middleware authenticates each request, attaches the client to its context and applies
+// the client's rate limit. When authentication is disabled clients are told apart by
+// remote address so the rate limit still applies per caller.
+func Middleware(authenticator *Authenticator, limiter *RateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var client *Client
+		if authenticator.Enabled() {
+			var err error
+			client, err = authenticator.Authenticate(r)
+			if err != nil {
+				reason := "invalid"
+				if errors.Is(err, ErrMissingCredentials) {
+					reason = "missing"
+				}
+				metrics.AuthFailures.WithLabelValues(reason).Inc()
+				slog.WarnContext(ctx, "authentication failed", "reason", reason, "error", err)
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sportsagent"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		} else {
+			client = &Client{ID: "ip:" + remoteHost(r), Method: MethodAnonymous, Scopes: []string{ScopeAll}}
+		}
+
+		if limiter != nil {
+			if ok, retryAfter := limiter.Allow(client.ID); !ok {
+				metrics.RateLimited.WithLabelValues(client.Method).Inc()
+				slog.WarnContext(ctx, "rate limit exceeded", "client", client.ID, "retry_after", retryAfter.String())
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClient(ctx, client)))
+	})
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
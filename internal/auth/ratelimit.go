@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"sportsagent/internal/config"
+
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long an unused bucket is kept; a client returning after that
+// starts with a full bucket, which is what it would have refilled to anyway
+const idleLimiterTTL = 10 * time.Minute
+
+// RateLimiter keeps a token bucket per client ID
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter returns nil when rate limiting is disabled
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	if cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		limit:   rate.Limit(cfg.RequestsPerMinute / 60),
+		burst:   cfg.Burst,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow takes a token for client. When the bucket is empty it reports how long until
+// the next token is available.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[client] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep drops idle buckets at most once per TTL; callers hold mu
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleLimiterTTL {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleLimiterTTL {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"path"
+	"slices"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/tools"
+)
+
+// ToolPolicy resolves client scopes to the tools they grant
+type ToolPolicy struct {
+	scopes map[string]config.ScopeConfig
+}
+
+func NewToolPolicy(scopes map[string]config.ScopeConfig) *ToolPolicy {
+	return &ToolPolicy{scopes: scopes}
+}
+
+// Access returns what a client holding scopes may call. Scopes that are not configured
+// grant nothing, so a token carrying only unrelated scopes sees no tools.
+func (p *ToolPolicy) Access(scopes []string) *ToolAccess {
+	access := &ToolAccess{}
+	for _, name := range scopes {
+		if name == ScopeAll {
+			access.all = true
+		}
+		if scope, ok := p.scopes[name]; ok {
+			access.grants = append(access.grants, scope)
+		}
+	}
+	return access
+}
+
+// ToolAccess is the set of tools available to one client
+type ToolAccess struct {
+	all    bool
+	grants []config.ScopeConfig
+}
+
+// Allows reports whether the tool may be offered to and called by the client. A nil
+// ToolAccess belongs to a trusted local caller such as the CLI and allows every tool.
+// A workflow also needs every tool it calls to be allowed.
+func (a *ToolAccess) Allows(name string) bool {
+	if a == nil || a.all {
+		return true
+	}
+
+	metadata, hasMetadata := tools.GetToolMetadata(name)
+	for _, step := range metadata.Steps {
+		if !a.Allows(step) {
+			return false
+		}
+	}
+	return a.granted(name, metadata, hasMetadata)
+}
+
+// AllowsExplicitly reports whether a configured scope names the tool, by its name or its
+// service, rather than the client holding every tool through the "*" scope. Direct
+// invocation requires it for tools that would otherwise need approval.
+func (a *ToolAccess) AllowsExplicitly(name string) bool {
+	if a == nil {
+		return true
+	}
+
+	metadata, hasMetadata := tools.GetToolMetadata(name)
+	for _, step := range metadata.Steps {
+		if !a.AllowsExplicitly(step) {
+			return false
+		}
+	}
+	return a.granted(name, metadata, hasMetadata)
+}
+
+func (a *ToolAccess) granted(name string, metadata tools.ToolMetadata, hasMetadata bool) bool {
+	for _, grant := range a.grants {
+		if !grantsTool(grant, name, metadata, hasMetadata) {
+			continue
+		}
+		if len(grant.Methods) == 0 || (hasMetadata && slices.Contains(grant.Methods, metadata.Method)) {
+			return true
+		}
+	}
+	return false
+}
+
+func grantsTool(grant config.ScopeConfig, name string, metadata tools.ToolMetadata, hasMetadata bool) bool {
+	if hasMetadata && slices.Contains(grant.Services, metadata.Service) {
+		return true
+	}
+	for _, pattern := range grant.Tools {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
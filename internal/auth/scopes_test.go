@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"testing"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/tools"
+	"sportsagent/internal/workflows"
+)
+
+func TestToolAccess(t *testing.T) {
+	flows, err := workflows.Parse([]byte(`
+workflows:
+  - name: news_with_odds
+    description: News and odds in one call
+    steps:
+      - {id: news, tool: get_roto_data}
+      - {id: odds, tool: get_odds_data}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools.SetWorkflows(flows)
+	t.Cleanup(func() { tools.SetWorkflows(nil) })
+
+	// Unreachable specs register the fallback tools and their metadata
+	tools.GetTools(tools.SpecSources("http://127.0.0.1:1", "http://127.0.0.1:1"))
+
+	policy := NewToolPolicy(map[string]config.ScopeConfig{
+		"news":       {Services: []string{tools.ServiceRotoReader}, Methods: []string{"GET"}},
+		"odds":       {Tools: []string{"get_odds_*"}},
+		"odds-write": {Services: []string{tools.ServiceOddsTracker}, Methods: []string{"POST"}},
+		"workflows":  {Services: []string{tools.ServiceWorkflow}},
+	})
+
+	tests := []struct {
+		name    string
+		access  *ToolAccess
+		allowed map[string]bool
+	}{
+		{"trusted caller", nil, map[string]bool{"get_roto_data": true, "get_odds_data": true}},
+		{"all", policy.Access([]string{ScopeAll}), map[string]bool{"get_roto_data": true, "get_odds_data": true}},
+		{"news", policy.Access([]string{"news"}), map[string]bool{"get_roto_data": true, "get_odds_data": false}},
+		{"news and odds", policy.Access([]string{"news", "odds"}), map[string]bool{"get_roto_data": true, "get_odds_data": true}},
+		{"method mismatch", policy.Access([]string{"odds-write"}), map[string]bool{"get_odds_data": false}},
+		{"unrelated scopes", policy.Access([]string{"openid", "profile"}), map[string]bool{"get_roto_data": false, "get_odds_data": false}},
+		{"workflow without its steps", policy.Access([]string{"workflows", "news"}), map[string]bool{"news_with_odds": false}},
+		{"workflow with its steps", policy.Access([]string{"workflows", "news", "odds"}), map[string]bool{"news_with_odds": true}},
+		{"steps without the workflow", policy.Access([]string{"news", "odds"}), map[string]bool{"news_with_odds": false}},
+		{"hallucinated tool", policy.Access([]string{"news"}), map[string]bool{"delete_everything": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for tool, want := range tt.allowed {
+				if got := tt.access.Allows(tool); got != want {
+					t.Errorf("Allows(%q) = %v, want %v", tool, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestToolAccessAllowsExplicitly(t *testing.T) {
+	// Unreachable specs register the fallback tools and their metadata
+	tools.GetTools(tools.SpecSources("http://127.0.0.1:1", "http://127.0.0.1:1"))
+
+	policy := NewToolPolicy(map[string]config.ScopeConfig{
+		"odds": {Tools: []string{"get_odds_*"}},
+	})
+
+	if !(*ToolAccess)(nil).AllowsExplicitly("get_odds_data") {
+		t.Error("a trusted caller should be allowed every tool explicitly")
+	}
+	if access := policy.Access([]string{ScopeAll}); !access.Allows("get_odds_data") || access.AllowsExplicitly("get_odds_data") {
+		t.Error("the * scope should allow but not name a tool")
+	}
+	if access := policy.Access([]string{ScopeAll, "odds"}); !access.AllowsExplicitly("get_odds_data") || access.AllowsExplicitly("get_roto_data") {
+		t.Error("a named scope should grant only its tools explicitly alongside *")
+	}
+}
+
+func TestScopesFromClaim(t *testing.T) {
+	if got := scopesFromClaim("news odds"); len(got) != 2 || got[1] != "odds" {
+		t.Errorf("space-separated claim parsed as %v", got)
+	}
+	if got := scopesFromClaim([]any{"news", 3, "odds"}); len(got) != 2 || got[0] != "news" {
+		t.Errorf("array claim parsed as %v", got)
+	}
+	if got := scopesFromClaim(nil); got != nil {
+		t.Errorf("missing claim parsed as %v", got)
+	}
+}
@@ -0,0 +1,283 @@
+// Package calendar resolves dates for tool arguments: the current time in the configured
+// timezone, relative expressions such as "tonight" or "next weekend", and league calendar
+// lookups such as the week of a season and its game days. League seasons come from a
+// built-in data file that a local file can extend or replace.
+package calendar
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin.yaml
+var builtin []byte
+
+// ErrUnknownLeague is returned for a league that has no calendar
+var ErrUnknownLeague = errors.New("unknown league")
+
+// file is the layout of a calendar data file
+type file struct {
+	Leagues []struct {
+		ID      string `yaml:"id"`
+		Name    string `yaml:"name"`
+		Seasons []struct {
+			Season   string   `yaml:"season"`
+			Start    string   `yaml:"start"`
+			End      string   `yaml:"end"`
+			Weeks    int      `yaml:"weeks"`
+			GameDays []string `yaml:"gameDays"`
+			Phases   []struct {
+				Name  string `yaml:"name"`
+				Start string `yaml:"start"`
+				End   string `yaml:"end"`
+			} `yaml:"phases"`
+		} `yaml:"seasons"`
+	} `yaml:"leagues"`
+}
+
+// League is a league and its seasons, oldest first
+type League struct {
+	ID      string
+	Name    string
+	Seasons []Season
+}
+
+// Season is one season of a league. Dates are days in the calendar's timezone.
+type Season struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	// Weeks is the number of seven-day weeks numbered from Start; 0 if the league does
+	// not number its weeks
+	Weeks    int
+	GameDays []time.Weekday
+	Phases   []Phase
+}
+
+// Phase is a named part of a season, such as the regular season or the playoffs
+type Phase struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Calendar holds the league calendars and the timezone dates are resolved in
+type Calendar struct {
+	location *time.Location
+	leagues  map[string]League
+	now      func() time.Time
+}
+
+// Load reads the built-in calendar and the leagues in path, which replace built-in leagues
+// with the same id. Dates are days in the named IANA timezone.
+func Load(path, timezone string) (*Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	c := &Calendar{location: location, leagues: map[string]League{}, now: time.Now}
+	if err := c.parse(builtin); err != nil {
+		return nil, fmt.Errorf("built-in calendar: %w", err)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.parse(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Calendar) parse(data []byte) error {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	for _, l := range f.Leagues {
+		id := strings.ToLower(strings.TrimSpace(l.ID))
+		if id == "" {
+			return errors.New("league without an id")
+		}
+		league := League{ID: id, Name: l.Name}
+		if league.Name == "" {
+			league.Name = strings.ToUpper(id)
+		}
+		for _, s := range l.Seasons {
+			season := Season{Name: s.Season, Weeks: s.Weeks}
+			var err error
+			if season.Start, season.End, err = c.dateRange(s.Start, s.End); err != nil {
+				return fmt.Errorf("%s season %s: %w", id, s.Season, err)
+			}
+			if season.Name == "" {
+				season.Name = season.Start.Format("2006")
+			}
+			if s.Weeks < 0 {
+				return fmt.Errorf("%s season %s: weeks must not be negative", id, season.Name)
+			}
+			for _, day := range s.GameDays {
+				weekday, ok := parseWeekday(strings.ToLower(day))
+				if !ok {
+					return fmt.Errorf("%s season %s: unknown game day %q", id, season.Name, day)
+				}
+				season.GameDays = append(season.GameDays, weekday)
+			}
+			for _, p := range s.Phases {
+				phase := Phase{Name: p.Name}
+				if phase.Start, phase.End, err = c.dateRange(p.Start, p.End); err != nil {
+					return fmt.Errorf("%s season %s phase %s: %w", id, season.Name, p.Name, err)
+				}
+				season.Phases = append(season.Phases, phase)
+			}
+			league.Seasons = append(league.Seasons, season)
+		}
+		sort.Slice(league.Seasons, func(i, j int) bool { return league.Seasons[i].Start.Before(league.Seasons[j].Start) })
+		c.leagues[id] = league
+	}
+	return nil
+}
+
+// dateRange parses an inclusive range of YYYY-MM-DD days
+func (c *Calendar) dateRange(start, end string) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(time.DateOnly, start, c.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	to, err := time.ParseInLocation(time.DateOnly, end, c.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end: %w", err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end %s is before start %s", end, start)
+	}
+	return from, to, nil
+}
+
+// Location returns the timezone dates are resolved in
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// Now returns the current time in the calendar's timezone
+func (c *Calendar) Now() time.Time {
+	return c.now().In(c.location)
+}
+
+// Leagues lists the ids of the leagues with a calendar
+func (c *Calendar) Leagues() []string {
+	ids := make([]string, 0, len(c.leagues))
+	for id := range c.leagues {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// League returns the calendar of a league by id, ignoring case
+func (c *Calendar) League(id string) (League, error) {
+	league, ok := c.leagues[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return League{}, fmt.Errorf("%w %q: calendars are available for %s", ErrUnknownLeague, id, strings.Join(c.Leagues(), ", "))
+	}
+	return league, nil
+}
+
+// SeasonAt returns the season covering day, or false in the offseason
+func (l League) SeasonAt(day time.Time) (Season, bool) {
+	for _, season := range l.Seasons {
+		if !day.Before(season.Start) && !day.After(season.End) {
+			return season, true
+		}
+	}
+	return Season{}, false
+}
+
+// Season returns a season by name, or the current one when name is empty: the season
+// covering day, else the next to start, else the latest
+func (l League) Season(name string, day time.Time) (Season, error) {
+	if name != "" {
+		for _, season := range l.Seasons {
+			if season.Name == name {
+				return season, nil
+			}
+		}
+		return Season{}, fmt.Errorf("no %s season %q in the calendar", l.Name, name)
+	}
+	if len(l.Seasons) == 0 {
+		return Season{}, fmt.Errorf("no %s seasons in the calendar", l.Name)
+	}
+	for _, season := range l.Seasons {
+		if !day.After(season.End) {
+			return season, nil
+		}
+	}
+	return l.Seasons[len(l.Seasons)-1], nil
+}
+
+// Week returns the number of the week containing day, or 0 outside the numbered weeks
+func (s Season) Week(day time.Time) int {
+	if s.Weeks == 0 || day.Before(s.Start) {
+		return 0
+	}
+	week := daysBetween(s.Start, day)/7 + 1
+	if week > s.Weeks {
+		return 0
+	}
+	return week
+}
+
+// WeekRange returns the first and last day of a numbered week
+func (s Season) WeekRange(week int) (time.Time, time.Time, error) {
+	if week < 1 || week > s.Weeks {
+		if s.Weeks == 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("season %s has no numbered weeks", s.Name)
+		}
+		return time.Time{}, time.Time{}, fmt.Errorf("season %s has weeks 1 to %d, not %d", s.Name, s.Weeks, week)
+	}
+	start := s.Start.AddDate(0, 0, 7*(week-1))
+	return start, start.AddDate(0, 0, 6), nil
+}
+
+// Phase returns the name of the phase covering day, or "" if none does
+func (s Season) Phase(day time.Time) string {
+	for _, phase := range s.Phases {
+		if !day.Before(phase.Start) && !day.After(phase.End) {
+			return phase.Name
+		}
+	}
+	return ""
+}
+
+// GameDaysBetween lists the game days from start to end inclusive
+func (s Season) GameDaysBetween(start, end time.Time) []time.Time {
+	var days []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Before(s.Start) || day.After(s.End) {
+			continue
+		}
+		for _, weekday := range s.GameDays {
+			if day.Weekday() == weekday {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+	return days
+}
+
+// daysBetween counts calendar days from a to b, which are midnights in the same zone
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	// Compare as UTC dates so daylight saving changes do not shorten a day
+	return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
@@ -0,0 +1,173 @@
+package calendar
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadCalendar(t *testing.T, data string) *Calendar {
+	t.Helper()
+
+	path := ""
+	if data != "" {
+		path = filepath.Join(t.TempDir(), "calendar.yaml")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := Load(path, "America/New_York")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return c
+}
+
+func TestResolve(t *testing.T) {
+	c := loadCalendar(t, "")
+	// Monday 19 October 2026, 10:00 in New York
+	now := time.Date(2026, time.October, 19, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		league     string
+		start, end string
+	}{
+		{expression: "", start: "2026-10-19", end: "2026-10-19"},
+		{expression: "Tomorrow", start: "2026-10-20", end: "2026-10-20"},
+		{expression: "yesterday", start: "2026-10-18", end: "2026-10-18"},
+		{expression: "monday", start: "2026-10-19", end: "2026-10-19"},
+		{expression: "sunday", start: "2026-10-25", end: "2026-10-25"},
+		{expression: "next sunday", start: "2026-11-01", end: "2026-11-01"},
+		{expression: "last sunday", start: "2026-10-18", end: "2026-10-18"},
+		{expression: "last monday", start: "2026-10-12", end: "2026-10-12"},
+		{expression: "this week", start: "2026-10-19", end: "2026-10-25"},
+		{expression: "last week", start: "2026-10-12", end: "2026-10-18"},
+		{expression: "this weekend", start: "2026-10-24", end: "2026-10-25"},
+		{expression: "next weekend", start: "2026-10-31", end: "2026-11-01"},
+		{expression: "next month", start: "2026-11-01", end: "2026-11-30"},
+		{expression: "next 3 days", start: "2026-10-19", end: "2026-10-21"},
+		{expression: "last 7 days", start: "2026-10-13", end: "2026-10-19"},
+		{expression: "in 2 days", start: "2026-10-21", end: "2026-10-21"},
+		{expression: "3 days ago", start: "2026-10-16", end: "2026-10-16"},
+		{expression: "2026-12-25", start: "2026-12-25", end: "2026-12-25"},
+		// NFL weeks run Tuesday to Monday from 8 September
+		{expression: "this week", league: "nfl", start: "2026-10-13", end: "2026-10-19"},
+		{expression: "next week", league: "NFL", start: "2026-10-20", end: "2026-10-26"},
+		{expression: "Week 12", league: "nfl", start: "2026-11-24", end: "2026-11-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression+" "+tt.league, func(t *testing.T) {
+			r, err := c.Resolve(tt.expression, tt.league, "", now)
+			if err != nil {
+				t.Fatalf("Resolve returned error: %v", err)
+			}
+			if got := r.Start.Format(time.DateOnly); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := r.End.Format(time.DateOnly); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestResolve_Tonight(t *testing.T) {
+	c := loadCalendar(t, "")
+
+	r, err := c.Resolve("tonight", "", "", time.Date(2026, time.October, 19, 14, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.From.Format(time.RFC3339); got != "2026-10-19T17:00:00-04:00" {
+		t.Errorf("from = %s, want the evening", got)
+	}
+	if got := r.To.Format(time.RFC3339); got != "2026-10-20T00:00:00-04:00" {
+		t.Errorf("to = %s, want midnight", got)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	c := loadCalendar(t, "")
+	now := time.Date(2026, time.October, 19, 14, 0, 0, 0, time.UTC)
+
+	if _, err := c.Resolve("the day after the game", "", "", now); !errors.Is(err, ErrUnknownExpression) {
+		t.Errorf("expected ErrUnknownExpression, got %v", err)
+	}
+	if _, err := c.Resolve("today", "curling", "", now); !errors.Is(err, ErrUnknownLeague) {
+		t.Errorf("expected ErrUnknownLeague, got %v", err)
+	}
+	if _, err := c.Resolve("week 3", "", "", now); err == nil {
+		t.Error("expected week N without a league to fail")
+	}
+	if _, err := c.Resolve("week 19", "nfl", "", now); err == nil {
+		t.Error("expected a week beyond the season to fail")
+	}
+}
+
+func TestLeagueWeek(t *testing.T) {
+	c := loadCalendar(t, "")
+
+	lw, err := c.LeagueWeek("nfl", time.Date(2026, time.October, 19, 14, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lw.Season != "2026" || lw.Phase != "regular season" || lw.Week != 6 {
+		t.Fatalf("unexpected league week %+v", lw)
+	}
+	var gameDays []string
+	for _, day := range lw.GameDays {
+		gameDays = append(gameDays, day.Format(time.DateOnly))
+	}
+	if len(gameDays) != 3 || gameDays[0] != "2026-10-15" || gameDays[2] != "2026-10-19" {
+		t.Errorf("unexpected game days %v", gameDays)
+	}
+
+	lw, err = c.LeagueWeek("nfl", time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil || lw.Phase != "offseason" || lw.Week != 0 {
+		t.Fatalf("expected the offseason, got %+v, %v", lw, err)
+	}
+}
+
+func TestLoad_FileReplacesLeague(t *testing.T) {
+	c := loadCalendar(t, `
+leagues:
+  - id: NFL
+    seasons:
+      - season: "2027"
+        start: 2027-09-07
+        end: 2028-02-13
+        weeks: 18
+  - id: nba
+    name: NBA
+    seasons:
+      - start: 2026-10-20
+        end: 2027-06-20
+        gameDays: [Monday, Tuesday, Wednesday, Thursday, Friday, Saturday, Sunday]
+`)
+
+	if got := c.Leagues(); len(got) != 2 || got[0] != "nba" || got[1] != "nfl" {
+		t.Fatalf("unexpected leagues %v", got)
+	}
+	nfl, _ := c.League("nfl")
+	if len(nfl.Seasons) != 1 || nfl.Seasons[0].Name != "2027" {
+		t.Fatalf("the file should replace the built-in NFL calendar, got %+v", nfl.Seasons)
+	}
+
+	lw, err := c.LeagueWeek("nba", time.Date(2026, time.November, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil || lw.Season != "2026" || lw.Phase != "season" || lw.Week != 0 {
+		t.Fatalf("unexpected NBA week %+v, %v", lw, err)
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.yaml")
+	os.WriteFile(path, []byte("leagues:\n  - id: nfl\n    seasons:\n      - start: 2026-09-08\n        end: 2026-09-01\n"), 0o644)
+
+	if _, err := Load(path, "UTC"); err == nil {
+		t.Fatal("expected an error for a season ending before it starts")
+	}
+}
@@ -0,0 +1,224 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownExpression is returned for date expressions Resolve does not understand
+var ErrUnknownExpression = errors.New("unknown date expression")
+
+// eveningHour is when "tonight" starts
+const eveningHour = 17
+
+// Range is a resolved span of days. From and To bound it in time, To exclusive, so
+// "tonight" starts in the evening rather than at midnight.
+type Range struct {
+	Start time.Time
+	End   time.Time
+	From  time.Time
+	To    time.Time
+}
+
+// LeagueWeek describes where a day falls in a league's calendar
+type LeagueWeek struct {
+	League string
+	Season string
+	// Phase is the season phase, or "offseason" outside every season
+	Phase string
+	// Week is the numbered week, or 0 outside the numbered weeks
+	Week      int
+	WeekStart time.Time
+	WeekEnd   time.Time
+	GameDays  []time.Time
+}
+
+var (
+	weekNumber = regexp.MustCompile(`^week (\d+)$`)
+	dayCount   = regexp.MustCompile(`^(next|last|past) (\d+) days?$`)
+	dayOffset  = regexp.MustCompile(`^(?:in (\d+) days?|(\d+) days? ago)$`)
+)
+
+// Resolve turns an expression into a range of days relative to now. Expressions are
+// "today", "tonight", "tomorrow", "yesterday", weekday names with an optional "this",
+// "next" or "last", "this/next/last week", "weekend" or "month", "next/last N days",
+// "in N days", "N days ago", a YYYY-MM-DD date, and "week N". Weeks are Monday to Sunday
+// unless a league is given, when they are the league's numbered weeks; "week N" needs
+// a league and is in season, or the current season when season is empty.
+func (c *Calendar) Resolve(expression, league, season string, now time.Time) (Range, error) {
+	now = now.In(c.location)
+	today := midnight(now)
+	expr := strings.Join(strings.Fields(strings.ToLower(expression)), " ")
+
+	var l *League
+	if league != "" {
+		found, err := c.League(league)
+		if err != nil {
+			return Range{}, err
+		}
+		l = &found
+	}
+
+	if m := weekNumber.FindStringSubmatch(expr); m != nil {
+		if l == nil {
+			return Range{}, fmt.Errorf("%q needs a league", expression)
+		}
+		s, err := l.Season(season, today)
+		if err != nil {
+			return Range{}, err
+		}
+		week, _ := strconv.Atoi(m[1])
+		start, end, err := s.WeekRange(week)
+		if err != nil {
+			return Range{}, err
+		}
+		return days(start, end), nil
+	}
+
+	switch expr {
+	case "", "today", "now":
+		return days(today, today), nil
+	case "tonight":
+		r := days(today, today)
+		if evening := today.Add(eveningHour * time.Hour); now.Before(evening) {
+			r.From = evening
+		} else {
+			r.From = now
+		}
+		return r, nil
+	case "tomorrow":
+		return days(today.AddDate(0, 0, 1), today.AddDate(0, 0, 1)), nil
+	case "yesterday":
+		return days(today.AddDate(0, 0, -1), today.AddDate(0, 0, -1)), nil
+	case "this week", "next week", "last week":
+		shift := 7 * offset(expr)
+		if l != nil {
+			if s, ok := l.SeasonAt(today); ok {
+				if week := s.Week(today); week > 0 {
+					if start, end, err := s.WeekRange(week + shift/7); err == nil {
+						return days(start, end), nil
+					}
+				}
+			}
+		}
+		monday := today.AddDate(0, 0, -daysSinceMonday(today)+shift)
+		return days(monday, monday.AddDate(0, 0, 6)), nil
+	case "weekend", "this weekend", "next weekend", "last weekend":
+		saturday := today.AddDate(0, 0, 5-daysSinceMonday(today)+7*offset(expr))
+		return days(saturday, saturday.AddDate(0, 0, 1)), nil
+	case "this month", "next month", "last month":
+		first := time.Date(today.Year(), today.Month()+time.Month(offset(expr)), 1, 0, 0, 0, 0, c.location)
+		return days(first, first.AddDate(0, 1, -1)), nil
+	}
+
+	if m := dayCount.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if n < 1 {
+			return Range{}, fmt.Errorf("%w: %q", ErrUnknownExpression, expression)
+		}
+		if m[1] == "next" {
+			return days(today, today.AddDate(0, 0, n-1)), nil
+		}
+		return days(today.AddDate(0, 0, -(n-1)), today), nil
+	}
+	if m := dayOffset.FindStringSubmatch(expr); m != nil {
+		if m[1] != "" {
+			n, _ := strconv.Atoi(m[1])
+			return days(today.AddDate(0, 0, n), today.AddDate(0, 0, n)), nil
+		}
+		n, _ := strconv.Atoi(m[2])
+		return days(today.AddDate(0, 0, -n), today.AddDate(0, 0, -n)), nil
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, expr, c.location); err == nil {
+		return days(day, day), nil
+	}
+
+	// Weekdays: "sunday" and "this sunday" are the next one from today, "next sunday"
+	// the one after, and "last sunday" the latest before today
+	word, name, _ := strings.Cut(expr, " ")
+	if name == "" {
+		word, name = "this", word
+	}
+	if weekday, ok := parseWeekday(name); ok {
+		ahead := (int(weekday) - int(today.Weekday()) + 7) % 7
+		switch word {
+		case "this", "on":
+		case "next":
+			ahead += 7
+		case "last":
+			ahead -= 7
+		default:
+			return Range{}, fmt.Errorf("%w: %q", ErrUnknownExpression, expression)
+		}
+		day := today.AddDate(0, 0, ahead)
+		return days(day, day), nil
+	}
+
+	return Range{}, fmt.Errorf("%w: %q", ErrUnknownExpression, expression)
+}
+
+// LeagueWeek describes the league's calendar on day
+func (c *Calendar) LeagueWeek(league string, day time.Time) (LeagueWeek, error) {
+	l, err := c.League(league)
+	if err != nil {
+		return LeagueWeek{}, err
+	}
+	day = midnight(day.In(c.location))
+
+	lw := LeagueWeek{League: l.Name, Phase: "offseason"}
+	s, ok := l.SeasonAt(day)
+	if !ok {
+		return lw, nil
+	}
+	lw.Season = s.Name
+	if phase := s.Phase(day); phase != "" {
+		lw.Phase = phase
+	} else {
+		lw.Phase = "season"
+	}
+	if week := s.Week(day); week > 0 {
+		lw.Week = week
+		lw.WeekStart, lw.WeekEnd, _ = s.WeekRange(week)
+		lw.GameDays = s.GameDaysBetween(lw.WeekStart, lw.WeekEnd)
+	}
+	return lw, nil
+}
+
+// days returns the range of whole days from start to end
+func days(start, end time.Time) Range {
+	return Range{Start: start, End: end, From: start, To: end.AddDate(0, 0, 1)}
+}
+
+// offset is -1, 0 or 1 for an expression starting with "last", "this" or "next"
+func offset(expr string) int {
+	switch {
+	case strings.HasPrefix(expr, "next "):
+		return 1
+	case strings.HasPrefix(expr, "last "):
+		return -1
+	default:
+		return 0
+	}
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func daysSinceMonday(day time.Time) int {
+	return (int(day.Weekday()) + 6) % 7
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if name == strings.ToLower(d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
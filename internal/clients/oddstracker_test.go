@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"sportsagent/internal/config"
 	"sportsagent/internal/tools"
 )
 
@@ -13,9 +14,14 @@ func TestOddsTrackerClient_Integration(t *testing.T) {
 		t.Skip("skipping integration test: set INTEGRATION_TESTS=1 to run")
 	}
 
-	client := NewOddsTrackerClient()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	client := NewOddsTrackerClient(cfg.Services.OddsTrackerURL)
 	ctx := context.Background()
-	tools.GetTools()
+	tools.GetTools(tools.SpecSources(cfg.Services.RotoReaderURL, cfg.Services.OddsTrackerURL))
 
 	result, err := client.ExecuteOperation(ctx, "get_odds_data", map[string]any{})
 
@@ -30,38 +36,16 @@ func TestOddsTrackerClient_Integration(t *testing.T) {
 	t.Logf("received data: %s", result)
 }
 
-func TestOddsTrackerClient_URLConfiguration(t *testing.T) {
-	tests := []struct {
-		name    string
-		envURL  string
-		wantURL string
-	}{
-		{
-			name:    "uses env var when set",
-			envURL:  "http://custom:9001",
-			wantURL: "http://custom:9001",
-		},
-		{
-			name:    "uses default when env var empty",
-			envURL:  "",
-			wantURL: "http://localhost:8082",
-		},
-	}
+func TestOddsTrackerClient_BuildRequestUsesBaseURL(t *testing.T) {
+	client := NewOddsTrackerClient("http://custom:9001/")
+	metadata := tools.ToolMetadata{Service: tools.ServiceOddsTracker, Method: "GET", Path: "/feed"}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.envURL != "" {
-				os.Setenv("ODDSTRACKER_SERVICE_URL", tt.envURL)
-				defer os.Unsetenv("ODDSTRACKER_SERVICE_URL")
-			} else {
-				os.Unsetenv("ODDSTRACKER_SERVICE_URL")
-			}
-
-			client := NewOddsTrackerClient()
+	req, err := client.BuildRequest(context.Background(), metadata, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("BuildRequest returned error: %v", err)
+	}
 
-			if client.baseURL != tt.wantURL {
-				t.Errorf("got baseURL %s, want %s", client.baseURL, tt.wantURL)
-			}
-		})
+	if got, want := req.URL.String(), "http://custom:9001/feed"; got != want {
+		t.Errorf("got URL %s, want %s", got, want)
 	}
 }
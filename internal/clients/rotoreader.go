@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
 	"sportsagent/internal/tools"
 
@@ -17,12 +16,7 @@ type RotoReaderClient struct {
 	client  *http.Client
 }
 
-func NewRotoReaderClient() *RotoReaderClient {
-	baseURL := os.Getenv("ROTOREADER_SERVICE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:8081"
-	}
-
+func NewRotoReaderClient(baseURL string) *RotoReaderClient {
 	return &RotoReaderClient{
 		baseURL: baseURL,
 		client: &http.Client{
@@ -32,23 +26,34 @@ func NewRotoReaderClient() *RotoReaderClient {
 }
 
 func (c *RotoReaderClient) CallOperation(ctx context.Context, metadata tools.ToolMetadata, params map[string]interface{}) (string, error) {
-	req, err := tools.BuildHTTPRequest(ctx, c.baseURL, metadata, params)
+	req, err := c.BuildRequest(ctx, metadata, params)
 	if err != nil {
 		return "", err
 	}
 
+	_, body, err := c.Send(req)
+	return body, err
+}
+
+// BuildRequest constructs the HTTP request for an operation without sending it
+func (c *RotoReaderClient) BuildRequest(ctx context.Context, metadata tools.ToolMetadata, params map[string]interface{}) (*http.Request, error) {
+	return tools.BuildHTTPRequest(ctx, c.baseURL, metadata, params)
+}
+
+// Send executes a request built by BuildRequest and returns the status code and body
+func (c *RotoReaderClient) Send(req *http.Request) (int, string, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", err
+		return 0, "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return resp.StatusCode, "", err
 	}
 
-	return string(body), nil
+	return resp.StatusCode, string(body), nil
 }
 
 func (c *RotoReaderClient) ExecuteOperation(ctx context.Context, operationID string, params map[string]interface{}) (string, error) {
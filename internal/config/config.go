@@ -0,0 +1,1075 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone validation must not depend on the host zoneinfo
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the effective configuration of the agent. Values are layered with the
+// precedence defaults < config file < environment variables < command-line flags.
+type Config struct {
+	Server        ServerConfig        `yaml:"server" toml:"server" json:"server"`
+	Services      ServicesConfig      `yaml:"services" toml:"services" json:"services"`
+	OpenAI        OpenAIConfig        `yaml:"openai" toml:"openai" json:"openai"`
+	Prompts       PromptsConfig       `yaml:"prompts" toml:"prompts" json:"prompts"`
+	Schemas       SchemasConfig       `yaml:"schemas" toml:"schemas" json:"schemas"`
+	Calendar      CalendarConfig      `yaml:"calendar" toml:"calendar" json:"calendar"`
+	Entities      EntitiesConfig      `yaml:"entities" toml:"entities" json:"entities"`
+	Workflows     WorkflowsConfig     `yaml:"workflows" toml:"workflows" json:"workflows"`
+	Approval      ApprovalConfig      `yaml:"approval" toml:"approval" json:"approval"`
+	Sources       SourcesConfig       `yaml:"sources" toml:"sources" json:"sources"`
+	ToolOutput    ToolOutputConfig    `yaml:"toolOutput" toml:"toolOutput" json:"toolOutput"`
+	ToolSelection ToolSelectionConfig `yaml:"toolSelection" toml:"toolSelection" json:"toolSelection"`
+	Telemetry     TelemetryConfig     `yaml:"telemetry" toml:"telemetry" json:"telemetry"`
+	Health        HealthConfig        `yaml:"health" toml:"health" json:"health"`
+	Usage         UsageConfig         `yaml:"usage" toml:"usage" json:"usage"`
+	Logging       LoggingConfig       `yaml:"logging" toml:"logging" json:"logging"`
+	Auth          AuthConfig          `yaml:"auth" toml:"auth" json:"auth"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr" json:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" toml:"readHeaderTimeout" json:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" toml:"readTimeout" json:"readTimeout"`
+	// WriteTimeout bounds a whole /query round trip, including every model and tool call
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout" json:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout" toml:"idleTimeout" json:"idleTimeout"`
+	// ShutdownTimeout is how long in-flight requests may drain after SIGINT/SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" json:"shutdownTimeout"`
+	// AdminToken enables the /admin endpoints for callers presenting it as a bearer token
+	AdminToken string `yaml:"adminToken" toml:"adminToken" json:"adminToken"`
+}
+
+type ServicesConfig struct {
+	RotoReaderURL  string `yaml:"rotoreaderUrl" toml:"rotoreaderUrl" json:"rotoreaderUrl"`
+	OddsTrackerURL string `yaml:"oddstrackerUrl" toml:"oddstrackerUrl" json:"oddstrackerUrl"`
+}
+
+type OpenAIConfig struct {
+	APIKey        string `yaml:"apiKey" toml:"apiKey" json:"apiKey"`
+	BaseURL       string `yaml:"baseUrl" toml:"baseUrl" json:"baseUrl"`
+	Model         string `yaml:"model" toml:"model" json:"model"`
+	MaxIterations int    `yaml:"maxIterations" toml:"maxIterations" json:"maxIterations"`
+	// Fallbacks are tried in order when a completion fails with a retryable error
+	Fallbacks []ModelEndpoint `yaml:"fallbacks" toml:"fallbacks" json:"fallbacks"`
+	Routing   RoutingConfig   `yaml:"routing" toml:"routing" json:"routing"`
+}
+
+// ModelEndpoint is a model and the provider serving it. An empty BaseURL or APIKey uses
+// the primary provider's.
+type ModelEndpoint struct {
+	Model   string `yaml:"model" toml:"model" json:"model"`
+	BaseURL string `yaml:"baseUrl" toml:"baseUrl" json:"baseUrl"`
+	APIKey  string `yaml:"apiKey" toml:"apiKey" json:"apiKey"`
+}
+
+// RoutingConfig picks a model per query when the request does not name one
+type RoutingConfig struct {
+	// Mode is "off", "heuristic" or "classifier"
+	Mode string `yaml:"mode" toml:"mode" json:"mode"`
+	// SimpleModel answers single lookups and ComplexModel multi-tool analysis; empty
+	// uses openai.model
+	SimpleModel  string `yaml:"simpleModel" toml:"simpleModel" json:"simpleModel"`
+	ComplexModel string `yaml:"complexModel" toml:"complexModel" json:"complexModel"`
+	// ClassifierModel labels queries in classifier mode; empty uses SimpleModel
+	ClassifierModel string `yaml:"classifierModel" toml:"classifierModel" json:"classifierModel"`
+}
+
+// PromptsConfig controls the system prompt, rendered from Go templates. Templates in Dir
+// are named after their file without the .tmpl extension and override the built-in ones.
+type PromptsConfig struct {
+	Dir string `yaml:"dir" toml:"dir" json:"dir"`
+	// Default is the template used when a request does not name one
+	Default string `yaml:"default" toml:"default" json:"default"`
+	// Timezone is the IANA zone used for the current date and time in prompts
+	Timezone string `yaml:"timezone" toml:"timezone" json:"timezone"`
+	// ReloadInterval is how often Dir is checked for changed templates; 0 disables reloading
+	ReloadInterval time.Duration `yaml:"reloadInterval" toml:"reloadInterval" json:"reloadInterval"`
+}
+
+// SchemasConfig points at named JSON Schemas for structured answers. Files in Dir are
+// named after the schema with a .json extension and override the built-in ones.
+type SchemasConfig struct {
+	Dir string `yaml:"dir" toml:"dir" json:"dir"`
+}
+
+// CalendarConfig points at league calendar data for the resolve_dates tool. Leagues in
+// File replace built-in leagues with the same id. Dates use prompts.timezone.
+type CalendarConfig struct {
+	File string `yaml:"file" toml:"file" json:"file"`
+}
+
+// EntitiesConfig controls resolving player and team names, which services spell
+// differently. Entities in File replace built-in ones with the same id.
+type EntitiesConfig struct {
+	File string `yaml:"file" toml:"file" json:"file"`
+	// MinScore is the similarity, from 0 to 1, a fuzzy match needs
+	MinScore float64 `yaml:"minScore" toml:"minScore" json:"minScore"`
+	// Normalize rewrites tool arguments that name an entity to the called service's spelling
+	Normalize bool `yaml:"normalize" toml:"normalize" json:"normalize"`
+	// Parameters are the argument names, or path.Match patterns, that Normalize rewrites
+	Parameters []string `yaml:"parameters" toml:"parameters" json:"parameters"`
+}
+
+// WorkflowsConfig points at composite tools: DAGs of other tools run as one tool call
+type WorkflowsConfig struct {
+	File string `yaml:"file" toml:"file" json:"file"`
+}
+
+// ApprovalConfig controls human confirmation of tool calls with side effects. Calls to
+// operations whose HTTP method is not GET, HEAD or OPTIONS pause the agent until a
+// client approves or rejects them.
+type ApprovalConfig struct {
+	Required bool `yaml:"required" toml:"required" json:"required"`
+	// TTL is how long a paused query waits for a decision before it is discarded
+	TTL time.Duration `yaml:"ttl" toml:"ttl" json:"ttl"`
+}
+
+// SourcesConfig controls how long raw tool payloads are kept for auditing the sources
+// cited in answers
+type SourcesConfig struct {
+	Retention time.Duration `yaml:"retention" toml:"retention" json:"retention"`
+	// MaxEntries bounds the payloads held in memory; the oldest are dropped first
+	MaxEntries int `yaml:"maxEntries" toml:"maxEntries" json:"maxEntries"`
+}
+
+// ToolOutputConfig controls how tool results are presented to the model. Output from
+// untrusted services, such as scraped news text, may carry instructions aimed at the model.
+type ToolOutputConfig struct {
+	// Envelope wraps every tool result in delimited <tool_output> tags marking it as data
+	Envelope bool `yaml:"envelope" toml:"envelope" json:"envelope"`
+	// Filter is "flag", "strip" or "off" for instruction-like text in untrusted output
+	Filter            string   `yaml:"filter" toml:"filter" json:"filter"`
+	UntrustedServices []string `yaml:"untrustedServices" toml:"untrustedServices" json:"untrustedServices"`
+	// AfterUntrusted is "read_only", "none" or "all": the tools the model may still call
+	// once untrusted output is in the conversation
+	AfterUntrusted string `yaml:"afterUntrusted" toml:"afterUntrusted" json:"afterUntrusted"`
+}
+
+// ToolSelectionConfig limits the tools sent with each completion to those ranked most
+// relevant to the query, so a large catalogue does not exceed tool limits or inflate prompts
+type ToolSelectionConfig struct {
+	// MaxTools is how many ranked tools are offered when more are available; 0 offers every tool
+	MaxTools int `yaml:"maxTools" toml:"maxTools" json:"maxTools"`
+	// Pinned tools, by name or path.Match pattern, are always offered in addition
+	Pinned     []string         `yaml:"pinned" toml:"pinned" json:"pinned"`
+	Embeddings EmbeddingsConfig `yaml:"embeddings" toml:"embeddings" json:"embeddings"`
+}
+
+// EmbeddingsConfig adds semantic similarity to the keyword ranking of tools
+type EmbeddingsConfig struct {
+	// Provider is "none" or "openai", which uses the openai endpoint and key
+	Provider string `yaml:"provider" toml:"provider" json:"provider"`
+	Model    string `yaml:"model" toml:"model" json:"model"`
+}
+
+// TelemetryConfig controls trace export. Tracing is disabled unless an exporter is
+// chosen or an OTLP endpoint is set.
+type TelemetryConfig struct {
+	// Exporter is "otlp", "stdout" or "none"; empty selects otlp when an endpoint is set
+	Exporter     string `yaml:"exporter" toml:"exporter" json:"exporter"`
+	OTLPEndpoint string `yaml:"otlpEndpoint" toml:"otlpEndpoint" json:"otlpEndpoint"`
+	// OTLPProtocol is "http/protobuf" or "grpc"
+	OTLPProtocol string            `yaml:"otlpProtocol" toml:"otlpProtocol" json:"otlpProtocol"`
+	OTLPHeaders  map[string]string `yaml:"otlpHeaders" toml:"otlpHeaders" json:"otlpHeaders"`
+	// OTLPInsecure disables TLS; http:// endpoints are always sent in plain text
+	OTLPInsecure bool      `yaml:"otlpInsecure" toml:"otlpInsecure" json:"otlpInsecure"`
+	TLS          TLSConfig `yaml:"tls" toml:"tls" json:"tls"`
+	// Sampler is one of always_on, always_off, traceidratio, parentbased_always_on,
+	// parentbased_always_off or parentbased_traceidratio
+	Sampler    string  `yaml:"sampler" toml:"sampler" json:"sampler"`
+	SamplerArg float64 `yaml:"samplerArg" toml:"samplerArg" json:"samplerArg"`
+	// Environment is reported as the deployment.environment.name resource attribute
+	Environment string `yaml:"environment" toml:"environment" json:"environment"`
+	// CaptureContent records prompts, model responses and tool payloads on GenAI spans.
+	// Off by default because they may contain user data.
+	CaptureContent bool `yaml:"captureContent" toml:"captureContent" json:"captureContent"`
+}
+
+// TLSConfig locates PEM files for a custom CA and an optional client certificate
+type TLSConfig struct {
+	CAFile   string `yaml:"caFile" toml:"caFile" json:"caFile"`
+	CertFile string `yaml:"certFile" toml:"certFile" json:"certFile"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile" json:"keyFile"`
+}
+
+// HealthConfig controls the dependency checks behind /readyz
+type HealthConfig struct {
+	// CacheTTL is how long a readiness report is reused before dependencies are probed again
+	CacheTTL time.Duration `yaml:"cacheTtl" toml:"cacheTtl" json:"cacheTtl"`
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout" json:"timeout"`
+	// Probe paths are requested on each backend; the OpenAPI spec endpoint by default
+	RotoReaderProbePath  string `yaml:"rotoreaderProbePath" toml:"rotoreaderProbePath" json:"rotoreaderProbePath"`
+	OddsTrackerProbePath string `yaml:"oddstrackerProbePath" toml:"oddstrackerProbePath" json:"oddstrackerProbePath"`
+}
+
+// UsageConfig prices model tokens and caps spend. A zero limit is disabled.
+type UsageConfig struct {
+	// Prices are USD per million tokens, keyed by model name or model name prefix
+	Prices map[string]ModelPrice `yaml:"prices" toml:"prices" json:"prices"`
+	// MaxRequestTokens and MaxRequestCost abort a single query once exceeded
+	MaxRequestTokens int64   `yaml:"maxRequestTokens" toml:"maxRequestTokens" json:"maxRequestTokens"`
+	MaxRequestCost   float64 `yaml:"maxRequestCost" toml:"maxRequestCost" json:"maxRequestCost"`
+	// DailyTokens and DailyCost cap each API key per UTC day
+	DailyTokens int64   `yaml:"dailyTokens" toml:"dailyTokens" json:"dailyTokens"`
+	DailyCost   float64 `yaml:"dailyCost" toml:"dailyCost" json:"dailyCost"`
+}
+
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt" toml:"prompt" json:"prompt"`
+	Completion float64 `yaml:"completion" toml:"completion" json:"completion"`
+}
+
+// LoggingConfig controls structured log output. Queries, responses and tool payloads
+// are only logged in full at debug level.
+type LoggingConfig struct {
+	// Level is debug, info, warn or error; it can be changed at runtime via /admin/log-level
+	Level string `yaml:"level" toml:"level" json:"level"`
+	// Format is json or text
+	Format string `yaml:"format" toml:"format" json:"format"`
+}
+
+// AuthConfig controls authentication of API callers. It is enabled as soon as an API key
+// or a JWKS file is configured; /livez, /readyz, /healthz and /metrics stay open.
+type AuthConfig struct {
+	APIKeys   []APIKeyConfig  `yaml:"apiKeys" toml:"apiKeys" json:"apiKeys"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt" json:"jwt"`
+	RateLimit RateLimitConfig `yaml:"rateLimit" toml:"rateLimit" json:"rateLimit"`
+	// Scopes name sets of tools that API keys and tokens can be granted. The built-in
+	// scope "*" grants every tool.
+	Scopes map[string]ScopeConfig `yaml:"scopes" toml:"scopes" json:"scopes"`
+}
+
+// ScopeConfig grants the tools matching Tools or belonging to Services, optionally
+// narrowed to the given HTTP methods, e.g. Methods: [GET] for read-only access
+type ScopeConfig struct {
+	// Tools are tool name patterns in path.Match syntax, e.g. "get_*"
+	Tools    []string `yaml:"tools" toml:"tools" json:"tools"`
+	Services []string `yaml:"services" toml:"services" json:"services"`
+	Methods  []string `yaml:"methods" toml:"methods" json:"methods"`
+}
+
+// APIKeyConfig is a static API key. Only its hash is stored, as "sha256:<hex>"; see
+// `sportsagent auth hash-key`.
+type APIKeyConfig struct {
+	// ID names the client in logs, usage reports and rate limits
+	ID   string `yaml:"id" toml:"id" json:"id"`
+	Hash string `yaml:"hash" toml:"hash" json:"hash"`
+	// Scopes limit the tools the key can use; a key without scopes is granted "*"
+	Scopes []string `yaml:"scopes" toml:"scopes" json:"scopes"`
+}
+
+// JWTConfig validates bearer tokens against keys in a local JWKS file
+type JWTConfig struct {
+	JWKSFile string `yaml:"jwksFile" toml:"jwksFile" json:"jwksFile"`
+	Issuer   string `yaml:"issuer" toml:"issuer" json:"issuer"`
+	Audience string `yaml:"audience" toml:"audience" json:"audience"`
+	// ClientClaim is the claim that identifies the client, "sub" by default
+	ClientClaim string `yaml:"clientClaim" toml:"clientClaim" json:"clientClaim"`
+	// ScopesClaim holds the token's scopes, space-separated or as an array; "scope" by default
+	ScopesClaim string `yaml:"scopesClaim" toml:"scopesClaim" json:"scopesClaim"`
+}
+
+// RateLimitConfig is a token bucket per client; zero RequestsPerMinute disables it
+type RateLimitConfig struct {
+	RequestsPerMinute float64 `yaml:"requestsPerMinute" toml:"requestsPerMinute" json:"requestsPerMinute"`
+	Burst             int     `yaml:"burst" toml:"burst" json:"burst"`
+}
+
+// Default returns the built-in configuration used when nothing else is set
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8082",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      2 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Services: ServicesConfig{
+			RotoReaderURL:  "http://localhost:8081",
+			OddsTrackerURL: "http://localhost:8000",
+		},
+		OpenAI: OpenAIConfig{
+			Model:         "gpt-4o",
+			MaxIterations: 5,
+			Routing:       RoutingConfig{Mode: "off"},
+		},
+		Prompts: PromptsConfig{
+			Default:        "default",
+			Timezone:       "UTC",
+			ReloadInterval: 5 * time.Second,
+		},
+		Approval: ApprovalConfig{
+			Required: true,
+			TTL:      15 * time.Minute,
+		},
+		Sources: SourcesConfig{
+			Retention:  24 * time.Hour,
+			MaxEntries: 10000,
+		},
+		ToolOutput: ToolOutputConfig{
+			Envelope:          true,
+			Filter:            "flag",
+			UntrustedServices: []string{"rotoreader"},
+			AfterUntrusted:    "read_only",
+		},
+		Entities: EntitiesConfig{
+			MinScore:   0.85,
+			Parameters: []string{"team", "*_team", "player", "*_player"},
+		},
+		ToolSelection: ToolSelectionConfig{
+			MaxTools:   20,
+			Embeddings: EmbeddingsConfig{Provider: "none", Model: "text-embedding-3-small"},
+		},
+		Telemetry: TelemetryConfig{
+			OTLPProtocol: "http/protobuf",
+			Sampler:      "parentbased_always_on",
+			SamplerArg:   1,
+		},
+		Usage: UsageConfig{
+			Prices: map[string]ModelPrice{
+				"gpt-4o":      {Prompt: 2.50, Completion: 10.00},
+				"gpt-4o-mini": {Prompt: 0.15, Completion: 0.60},
+			},
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Auth: AuthConfig{
+			JWT: JWTConfig{ClientClaim: "sub", ScopesClaim: "scope"},
+			RateLimit: RateLimitConfig{
+				RequestsPerMinute: 30,
+				Burst:             10,
+			},
+		},
+		Health: HealthConfig{
+			CacheTTL:             10 * time.Second,
+			Timeout:              2 * time.Second,
+			RotoReaderProbePath:  "/openapi.json",
+			OddsTrackerProbePath: "/openapi.json",
+		},
+	}
+}
+
+// setting maps a configuration key to its environment variable and command-line flag
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{
+		key: "server.addr", env: "SPORTSAGENT_ADDR", flag: "addr",
+		usage: "address to listen on",
+		set:   func(c *Config, v string) error { c.Server.Addr = v; return nil },
+	},
+	{
+		key: "server.shutdownTimeout", env: "SPORTSAGENT_SHUTDOWN_TIMEOUT", flag: "shutdown-timeout",
+		usage: "how long in-flight requests may drain on shutdown",
+		set:   func(c *Config, v string) error { return setDuration(&c.Server.ShutdownTimeout, v) },
+	},
+	{
+		key: "server.adminToken", env: "SPORTSAGENT_ADMIN_TOKEN",
+		set: func(c *Config, v string) error { c.Server.AdminToken = v; return nil },
+	},
+	{
+		key: "services.rotoreaderUrl", env: "ROTOREADER_SERVICE_URL", flag: "rotoreader-url",
+		usage: "base URL of the rotoreader service",
+		set:   func(c *Config, v string) error { c.Services.RotoReaderURL = v; return nil },
+	},
+	{
+		key: "services.oddstrackerUrl", env: "ODDSTRACKER_SERVICE_URL", flag: "oddstracker-url",
+		usage: "base URL of the oddstracker service",
+		set:   func(c *Config, v string) error { c.Services.OddsTrackerURL = v; return nil },
+	},
+	{
+		key: "openai.apiKey", env: "OPENAI_API_KEY",
+		set: func(c *Config, v string) error { c.OpenAI.APIKey = v; return nil },
+	},
+	{
+		key: "openai.baseUrl", env: "OPENAI_BASE_URL",
+		set: func(c *Config, v string) error { c.OpenAI.BaseURL = v; return nil },
+	},
+	{
+		key: "openai.model", env: "OPENAI_MODEL", flag: "model",
+		usage: "default chat completion model",
+		set:   func(c *Config, v string) error { c.OpenAI.Model = v; return nil },
+	},
+	{
+		key: "openai.maxIterations", env: "SPORTSAGENT_MAX_ITERATIONS",
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("must be an integer, got %q", v)
+			}
+			c.OpenAI.MaxIterations = n
+			return nil
+		},
+	},
+	{
+		key: "openai.fallbacks", env: "SPORTSAGENT_FALLBACK_MODELS",
+		set: func(c *Config, v string) error {
+			var models []string
+			setList(&models, v)
+			c.OpenAI.Fallbacks = nil
+			for _, model := range models {
+				c.OpenAI.Fallbacks = append(c.OpenAI.Fallbacks, ModelEndpoint{Model: model})
+			}
+			return nil
+		},
+	},
+	{
+		key: "openai.routing.mode", env: "SPORTSAGENT_ROUTING",
+		set: func(c *Config, v string) error { c.OpenAI.Routing.Mode = v; return nil },
+	},
+	{
+		key: "openai.routing.simpleModel", env: "SPORTSAGENT_SIMPLE_MODEL",
+		set: func(c *Config, v string) error { c.OpenAI.Routing.SimpleModel = v; return nil },
+	},
+	{
+		key: "openai.routing.complexModel", env: "SPORTSAGENT_COMPLEX_MODEL",
+		set: func(c *Config, v string) error { c.OpenAI.Routing.ComplexModel = v; return nil },
+	},
+	{
+		key: "prompts.dir", env: "SPORTSAGENT_PROMPTS_DIR", flag: "prompts-dir",
+		usage: "directory of system prompt templates",
+		set:   func(c *Config, v string) error { c.Prompts.Dir = v; return nil },
+	},
+	{
+		key: "prompts.default", env: "SPORTSAGENT_PROMPT", flag: "prompt",
+		usage: "system prompt template to use when a request does not name one",
+		set:   func(c *Config, v string) error { c.Prompts.Default = v; return nil },
+	},
+	{
+		key: "prompts.timezone", env: "SPORTSAGENT_TIMEZONE", flag: "timezone",
+		usage: "IANA timezone for dates in prompts",
+		set:   func(c *Config, v string) error { c.Prompts.Timezone = v; return nil },
+	},
+	{
+		key: "schemas.dir", env: "SPORTSAGENT_SCHEMAS_DIR",
+		set: func(c *Config, v string) error { c.Schemas.Dir = v; return nil },
+	},
+	{
+		key: "calendar.file", env: "SPORTSAGENT_CALENDAR_FILE",
+		set: func(c *Config, v string) error { c.Calendar.File = v; return nil },
+	},
+	{
+		key: "entities.file", env: "SPORTSAGENT_ENTITIES_FILE",
+		set: func(c *Config, v string) error { c.Entities.File = v; return nil },
+	},
+	{
+		key: "entities.minScore", env: "SPORTSAGENT_ENTITY_MIN_SCORE",
+		set: func(c *Config, v string) error { return setFloat(&c.Entities.MinScore, v) },
+	},
+	{
+		key: "entities.normalize", env: "SPORTSAGENT_NORMALIZE_ARGUMENTS",
+		set: func(c *Config, v string) error { return setBool(&c.Entities.Normalize, v) },
+	},
+	{
+		key: "entities.parameters", env: "SPORTSAGENT_ENTITY_PARAMETERS",
+		set: func(c *Config, v string) error { return setList(&c.Entities.Parameters, v) },
+	},
+	{
+		key: "workflows.file", env: "SPORTSAGENT_WORKFLOWS_FILE",
+		set: func(c *Config, v string) error { c.Workflows.File = v; return nil },
+	},
+	{
+		key: "approval.required", env: "SPORTSAGENT_APPROVAL_REQUIRED",
+		set: func(c *Config, v string) error { return setBool(&c.Approval.Required, v) },
+	},
+	{
+		key: "approval.ttl", env: "SPORTSAGENT_APPROVAL_TTL",
+		set: func(c *Config, v string) error { return setDuration(&c.Approval.TTL, v) },
+	},
+	{
+		key: "sources.retention", env: "SPORTSAGENT_SOURCE_RETENTION",
+		set: func(c *Config, v string) error { return setDuration(&c.Sources.Retention, v) },
+	},
+	{
+		key: "toolOutput.envelope", env: "SPORTSAGENT_TOOL_OUTPUT_ENVELOPE",
+		set: func(c *Config, v string) error { return setBool(&c.ToolOutput.Envelope, v) },
+	},
+	{
+		key: "toolOutput.filter", env: "SPORTSAGENT_TOOL_OUTPUT_FILTER",
+		set: func(c *Config, v string) error { c.ToolOutput.Filter = v; return nil },
+	},
+	{
+		key: "toolOutput.untrustedServices", env: "SPORTSAGENT_UNTRUSTED_SERVICES",
+		set: func(c *Config, v string) error { return setList(&c.ToolOutput.UntrustedServices, v) },
+	},
+	{
+		key: "toolOutput.afterUntrusted", env: "SPORTSAGENT_AFTER_UNTRUSTED",
+		set: func(c *Config, v string) error { c.ToolOutput.AfterUntrusted = v; return nil },
+	},
+	{
+		key: "toolSelection.maxTools", env: "SPORTSAGENT_MAX_TOOLS",
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("must be an integer, got %q", v)
+			}
+			c.ToolSelection.MaxTools = n
+			return nil
+		},
+	},
+	{
+		key: "toolSelection.pinned", env: "SPORTSAGENT_PINNED_TOOLS",
+		set: func(c *Config, v string) error { return setList(&c.ToolSelection.Pinned, v) },
+	},
+	{
+		key: "toolSelection.embeddings.provider", env: "SPORTSAGENT_TOOL_EMBEDDINGS",
+		set: func(c *Config, v string) error { c.ToolSelection.Embeddings.Provider = v; return nil },
+	},
+	{
+		key: "telemetry.otlpEndpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", flag: "otel-endpoint",
+		usage: "OTLP trace exporter endpoint",
+		set:   func(c *Config, v string) error { c.Telemetry.OTLPEndpoint = v; return nil },
+	},
+	{
+		key: "telemetry.exporter", env: "OTEL_TRACES_EXPORTER", flag: "trace-exporter",
+		usage: "trace exporter: otlp, stdout or none",
+		set:   func(c *Config, v string) error { c.Telemetry.Exporter = v; return nil },
+	},
+	{
+		key: "telemetry.otlpProtocol", env: "OTEL_EXPORTER_OTLP_PROTOCOL",
+		set: func(c *Config, v string) error { c.Telemetry.OTLPProtocol = v; return nil },
+	},
+	{
+		key: "telemetry.otlpHeaders", env: "OTEL_EXPORTER_OTLP_HEADERS",
+		set: func(c *Config, v string) error { return setHeaders(&c.Telemetry.OTLPHeaders, v) },
+	},
+	{
+		key: "telemetry.otlpInsecure", env: "OTEL_EXPORTER_OTLP_INSECURE",
+		set: func(c *Config, v string) error { return setBool(&c.Telemetry.OTLPInsecure, v) },
+	},
+	{
+		key: "telemetry.tls.caFile", env: "OTEL_EXPORTER_OTLP_CERTIFICATE",
+		set: func(c *Config, v string) error { c.Telemetry.TLS.CAFile = v; return nil },
+	},
+	{
+		key: "telemetry.tls.certFile", env: "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE",
+		set: func(c *Config, v string) error { c.Telemetry.TLS.CertFile = v; return nil },
+	},
+	{
+		key: "telemetry.tls.keyFile", env: "OTEL_EXPORTER_OTLP_CLIENT_KEY",
+		set: func(c *Config, v string) error { c.Telemetry.TLS.KeyFile = v; return nil },
+	},
+	{
+		key: "telemetry.sampler", env: "OTEL_TRACES_SAMPLER",
+		set: func(c *Config, v string) error { c.Telemetry.Sampler = v; return nil },
+	},
+	{
+		key: "telemetry.samplerArg", env: "OTEL_TRACES_SAMPLER_ARG",
+		set: func(c *Config, v string) error { return setFloat(&c.Telemetry.SamplerArg, v) },
+	},
+	{
+		key: "telemetry.environment", env: "SPORTSAGENT_ENVIRONMENT", flag: "environment",
+		usage: "deployment environment reported on traces",
+		set:   func(c *Config, v string) error { c.Telemetry.Environment = v; return nil },
+	},
+	{
+		key: "telemetry.captureContent", env: "OTEL_INSTRUMENTATION_GENAI_CAPTURE_MESSAGE_CONTENT",
+		set: func(c *Config, v string) error { return setBool(&c.Telemetry.CaptureContent, v) },
+	},
+	{
+		key: "logging.level", env: "SPORTSAGENT_LOG_LEVEL", flag: "log-level",
+		usage: "log level: debug, info, warn or error",
+		set:   func(c *Config, v string) error { c.Logging.Level = v; return nil },
+	},
+	{
+		key: "logging.format", env: "SPORTSAGENT_LOG_FORMAT",
+		set: func(c *Config, v string) error { c.Logging.Format = v; return nil },
+	},
+	{
+		key: "auth.jwt.jwksFile", env: "SPORTSAGENT_JWKS_FILE",
+		set: func(c *Config, v string) error { c.Auth.JWT.JWKSFile = v; return nil },
+	},
+	{
+		key: "auth.rateLimit.requestsPerMinute", env: "SPORTSAGENT_RATE_LIMIT",
+		set: func(c *Config, v string) error { return setFloat(&c.Auth.RateLimit.RequestsPerMinute, v) },
+	},
+	{
+		key: "usage.maxRequestTokens", env: "SPORTSAGENT_MAX_REQUEST_TOKENS",
+		set: func(c *Config, v string) error { return setInt64(&c.Usage.MaxRequestTokens, v) },
+	},
+	{
+		key: "usage.maxRequestCost", env: "SPORTSAGENT_MAX_REQUEST_COST",
+		set: func(c *Config, v string) error { return setFloat(&c.Usage.MaxRequestCost, v) },
+	},
+	{
+		key: "usage.dailyTokens", env: "SPORTSAGENT_DAILY_TOKENS",
+		set: func(c *Config, v string) error { return setInt64(&c.Usage.DailyTokens, v) },
+	},
+	{
+		key: "usage.dailyCost", env: "SPORTSAGENT_DAILY_COST",
+		set: func(c *Config, v string) error { return setFloat(&c.Usage.DailyCost, v) },
+	},
+}
+
+func setDuration(dst *time.Duration, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 30s, got %q", value)
+	}
+	*dst = d
+	return nil
+}
+
+func setBool(dst *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("must be true or false, got %q", value)
+	}
+	*dst = b
+	return nil
+}
+
+func setInt64(dst *int64, value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be an integer, got %q", value)
+	}
+	*dst = n
+	return nil
+}
+
+func setFloat(dst *float64, value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("must be a number, got %q", value)
+	}
+	*dst = f
+	return nil
+}
+
+// setList parses a comma-separated list, ignoring empty entries
+func setList(dst *[]string, value string) error {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+	return nil
+}
+
+// setHeaders parses the OTLP header format key1=value1,key2=value2
+func setHeaders(dst *map[string]string, value string) error {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("must be key=value pairs separated by commas, got %q", pair)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	*dst = headers
+	return nil
+}
+
+// Load builds the configuration from defaults, the optional config file and the
+// environment, and validates the result.
+func Load(path string) (*Config, error) {
+	cfg, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("SPORTSAGENT_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return nil, fmt.Errorf("config: %s from $%s: %w", s.key, s.env, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: failed to read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config: failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config: failed to parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config: unknown field %s in %s", undecoded[0], path)
+		}
+	default:
+		return fmt.Errorf("config: unsupported file type %q for %s (use .yaml, .yml or .toml)", filepath.Ext(path), path)
+	}
+
+	return nil
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr %q is not a valid listen address: %v", c.Server.Addr, err))
+	} else {
+		if err := validateServiceURL(c.Services.RotoReaderURL, port); err != nil {
+			errs = append(errs, fmt.Errorf("services.rotoreaderUrl: %v", err))
+		}
+		if err := validateServiceURL(c.Services.OddsTrackerURL, port); err != nil {
+			errs = append(errs, fmt.Errorf("services.oddstrackerUrl: %v", err))
+		}
+	}
+
+	for key, d := range map[string]time.Duration{
+		"server.readHeaderTimeout": c.Server.ReadHeaderTimeout,
+		"server.readTimeout":       c.Server.ReadTimeout,
+		"server.writeTimeout":      c.Server.WriteTimeout,
+		"server.idleTimeout":       c.Server.IdleTimeout,
+		"server.shutdownTimeout":   c.Server.ShutdownTimeout,
+		"health.timeout":           c.Health.Timeout,
+		"approval.ttl":             c.Approval.TTL,
+		"sources.retention":        c.Sources.Retention,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", key, d))
+		}
+	}
+
+	if c.Health.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("health.cacheTtl must not be negative, got %s", c.Health.CacheTTL))
+	}
+	for key, path := range map[string]string{
+		"health.rotoreaderProbePath":  c.Health.RotoReaderProbePath,
+		"health.oddstrackerProbePath": c.Health.OddsTrackerProbePath,
+	} {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("%s must start with /, got %q", key, path))
+		}
+	}
+
+	if c.OpenAI.BaseURL != "" {
+		if _, err := validateURL(c.OpenAI.BaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("openai.baseUrl: %v", err))
+		}
+	}
+	if c.OpenAI.Model == "" {
+		errs = append(errs, errors.New("openai.model must not be empty"))
+	}
+	if c.OpenAI.MaxIterations < 1 {
+		errs = append(errs, fmt.Errorf("openai.maxIterations must be at least 1, got %d", c.OpenAI.MaxIterations))
+	}
+	for i, fallback := range c.OpenAI.Fallbacks {
+		if fallback.Model == "" {
+			errs = append(errs, fmt.Errorf("openai.fallbacks[%d].model must not be empty", i))
+		}
+		if fallback.BaseURL != "" {
+			if _, err := validateURL(fallback.BaseURL); err != nil {
+				errs = append(errs, fmt.Errorf("openai.fallbacks[%d].baseUrl: %v", i, err))
+			}
+		}
+	}
+	switch c.OpenAI.Routing.Mode {
+	case "off", "heuristic", "classifier":
+	default:
+		errs = append(errs, fmt.Errorf("openai.routing.mode must be off, heuristic or classifier, got %q", c.OpenAI.Routing.Mode))
+	}
+
+	if c.Prompts.Default == "" {
+		errs = append(errs, errors.New("prompts.default must not be empty"))
+	}
+	if _, err := time.LoadLocation(c.Prompts.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("prompts.timezone: %v", err))
+	}
+	if c.Prompts.ReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("prompts.reloadInterval must not be negative, got %s", c.Prompts.ReloadInterval))
+	}
+	for key, dir := range map[string]string{
+		"prompts.dir": c.Prompts.Dir,
+		"schemas.dir": c.Schemas.Dir,
+	} {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("%s %q is not a readable directory", key, dir))
+		}
+	}
+	for key, f := range map[string]string{
+		"calendar.file":  c.Calendar.File,
+		"entities.file":  c.Entities.File,
+		"workflows.file": c.Workflows.File,
+	} {
+		if f == "" {
+			continue
+		}
+		if info, err := os.Stat(f); err != nil || info.IsDir() {
+			errs = append(errs, fmt.Errorf("%s %q is not a readable file", key, f))
+		}
+	}
+	if c.Entities.MinScore <= 0 || c.Entities.MinScore > 1 {
+		errs = append(errs, fmt.Errorf("entities.minScore must be above 0 and at most 1, got %g", c.Entities.MinScore))
+	}
+	for _, pattern := range c.Entities.Parameters {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("entities.parameters: bad pattern %q", pattern))
+		}
+	}
+
+	if c.Sources.MaxEntries < 1 {
+		errs = append(errs, fmt.Errorf("sources.maxEntries must be at least 1, got %d", c.Sources.MaxEntries))
+	}
+
+	switch c.ToolOutput.Filter {
+	case "flag", "strip", "off":
+	default:
+		errs = append(errs, fmt.Errorf("toolOutput.filter must be flag, strip or off, got %q", c.ToolOutput.Filter))
+	}
+	switch c.ToolOutput.AfterUntrusted {
+	case "read_only", "none", "all":
+	default:
+		errs = append(errs, fmt.Errorf("toolOutput.afterUntrusted must be read_only, none or all, got %q", c.ToolOutput.AfterUntrusted))
+	}
+
+	if c.ToolSelection.MaxTools < 0 {
+		errs = append(errs, fmt.Errorf("toolSelection.maxTools must not be negative, got %d", c.ToolSelection.MaxTools))
+	}
+	for _, pattern := range c.ToolSelection.Pinned {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("toolSelection.pinned: bad pattern %q", pattern))
+		}
+	}
+	switch c.ToolSelection.Embeddings.Provider {
+	case "none":
+	case "openai":
+		if c.ToolSelection.Embeddings.Model == "" {
+			errs = append(errs, errors.New("toolSelection.embeddings.model must not be empty"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("toolSelection.embeddings.provider must be none or openai, got %q", c.ToolSelection.Embeddings.Provider))
+	}
+
+	errs = append(errs, c.Telemetry.validate()...)
+	errs = append(errs, c.Usage.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level must be debug, info, warn or error, got %q", c.Logging.Level))
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		errs = append(errs, fmt.Errorf("logging.format must be json or text, got %q", c.Logging.Format))
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+// validateServiceURL checks a backend URL and rejects one pointing back at the agent itself
+func validateServiceURL(raw, listenPort string) error {
+	u, err := validateURL(raw)
+	if err != nil {
+		return err
+	}
+
+	host := u.Hostname()
+	local := host == "localhost" || host == "127.0.0.1" || host == "::1"
+	if local && u.Port() == listenPort {
+		return fmt.Errorf("%q points at the agent's own listen port %s", raw, listenPort)
+	}
+	return nil
+}
+
+func validateURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid URL: %v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q must use http or https", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q is missing a host", raw)
+	}
+	return u, nil
+}
+
+func (t TelemetryConfig) validate() []error {
+	var errs []error
+
+	switch t.Exporter {
+	case "", "none", "otlp", "stdout", "console":
+	default:
+		errs = append(errs, fmt.Errorf("telemetry.exporter must be otlp, stdout, console or none, got %q", t.Exporter))
+	}
+	if t.Exporter == "otlp" && t.OTLPEndpoint == "" {
+		errs = append(errs, errors.New("telemetry.otlpEndpoint is required when telemetry.exporter is otlp"))
+	}
+	if t.OTLPEndpoint != "" {
+		if _, err := validateURL(t.OTLPEndpoint); err != nil {
+			errs = append(errs, fmt.Errorf("telemetry.otlpEndpoint: %v", err))
+		}
+	}
+
+	switch t.OTLPProtocol {
+	case "http/protobuf", "grpc":
+	default:
+		errs = append(errs, fmt.Errorf("telemetry.otlpProtocol must be http/protobuf or grpc, got %q", t.OTLPProtocol))
+	}
+
+	if (t.TLS.CertFile == "") != (t.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("telemetry.tls.certFile and telemetry.tls.keyFile must be set together"))
+	}
+
+	switch t.Sampler {
+	case "always_on", "always_off", "parentbased_always_on", "parentbased_always_off":
+	case "traceidratio", "parentbased_traceidratio":
+		if t.SamplerArg < 0 || t.SamplerArg > 1 {
+			errs = append(errs, fmt.Errorf("telemetry.samplerArg must be between 0 and 1, got %v", t.SamplerArg))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("telemetry.sampler %q is not supported", t.Sampler))
+	}
+
+	return errs
+}
+
+func (u UsageConfig) validate() []error {
+	var errs []error
+
+	for key, v := range map[string]float64{
+		"usage.maxRequestTokens": float64(u.MaxRequestTokens),
+		"usage.maxRequestCost":   u.MaxRequestCost,
+		"usage.dailyTokens":      float64(u.DailyTokens),
+		"usage.dailyCost":        u.DailyCost,
+	} {
+		if v < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %v", key, v))
+		}
+	}
+	for model, price := range u.Prices {
+		if price.Prompt < 0 || price.Completion < 0 {
+			errs = append(errs, fmt.Errorf("usage.prices.%s must not be negative", model))
+		}
+	}
+
+	return errs
+}
+
+func (a AuthConfig) validate() []error {
+	var errs []error
+
+	ids := map[string]bool{}
+	for i, key := range a.APIKeys {
+		if key.ID == "" {
+			errs = append(errs, fmt.Errorf("auth.apiKeys[%d].id must not be empty", i))
+		} else if ids[key.ID] {
+			errs = append(errs, fmt.Errorf("auth.apiKeys[%d].id %q is used more than once", i, key.ID))
+		}
+		ids[key.ID] = true
+
+		digest, ok := strings.CutPrefix(key.Hash, "sha256:")
+		if decoded, err := hex.DecodeString(digest); !ok || err != nil || len(decoded) != sha256.Size {
+			errs = append(errs, fmt.Errorf("auth.apiKeys[%d].hash must be sha256:<64 hex characters>", i))
+		}
+		for _, scope := range key.Scopes {
+			if _, ok := a.Scopes[scope]; !ok && scope != "*" {
+				errs = append(errs, fmt.Errorf("auth.apiKeys[%d].scopes: scope %q is not defined in auth.scopes", i, scope))
+			}
+		}
+	}
+
+	for name, scope := range a.Scopes {
+		if name == "" || name == "*" {
+			errs = append(errs, fmt.Errorf("auth.scopes: %q is not a valid scope name", name))
+		}
+		if len(scope.Tools) == 0 && len(scope.Services) == 0 {
+			errs = append(errs, fmt.Errorf("auth.scopes.%s must list tools or services", name))
+		}
+		for _, pattern := range scope.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("auth.scopes.%s.tools: bad pattern %q", name, pattern))
+			}
+		}
+		for _, method := range scope.Methods {
+			if method != strings.ToUpper(method) || method == "" {
+				errs = append(errs, fmt.Errorf("auth.scopes.%s.methods: %q must be an upper-case HTTP method", name, method))
+			}
+		}
+	}
+
+	if a.JWT.JWKSFile != "" && a.JWT.ClientClaim == "" {
+		errs = append(errs, errors.New("auth.jwt.clientClaim must not be empty"))
+	}
+	if a.JWT.JWKSFile != "" && a.JWT.ScopesClaim == "" {
+		errs = append(errs, errors.New("auth.jwt.scopesClaim must not be empty"))
+	}
+
+	if a.RateLimit.RequestsPerMinute < 0 {
+		errs = append(errs, fmt.Errorf("auth.rateLimit.requestsPerMinute must not be negative, got %v", a.RateLimit.RequestsPerMinute))
+	}
+	if a.RateLimit.RequestsPerMinute > 0 && a.RateLimit.Burst < 1 {
+		errs = append(errs, fmt.Errorf("auth.rateLimit.burst must be at least 1, got %d", a.RateLimit.Burst))
+	}
+
+	return errs
+}
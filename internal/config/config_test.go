@@ -0,0 +1,275 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("SPORTSAGENT_CONFIG", "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Server.Addr != ":8082" {
+		t.Errorf("got addr %s, want :8082", cfg.Server.Addr)
+	}
+	if cfg.Services.RotoReaderURL != "http://localhost:8081" {
+		t.Errorf("got rotoreader URL %s", cfg.Services.RotoReaderURL)
+	}
+	if cfg.Services.OddsTrackerURL == "http://localhost:8082" {
+		t.Error("oddstracker default must not point at the agent's own port")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+
+	path := writeFile(t, "config.yaml", `
+services:
+  rotoreaderUrl: http://file-roto:9000
+  oddstrackerUrl: http://file-odds:9001
+openai:
+  model: gpt-4o-mini
+`)
+	t.Setenv("ODDSTRACKER_SERVICE_URL", "http://env-odds:9001")
+	t.Setenv("OPENAI_MODEL", "env-model")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"-config", path, "-model", "flag-model"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	cfg, err := flags.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Services.RotoReaderURL != "http://file-roto:9000" {
+		t.Errorf("file value not applied, got %s", cfg.Services.RotoReaderURL)
+	}
+	if cfg.Services.OddsTrackerURL != "http://env-odds:9001" {
+		t.Errorf("env should override file, got %s", cfg.Services.OddsTrackerURL)
+	}
+	if cfg.OpenAI.Model != "flag-model" {
+		t.Errorf("flag should override env, got %s", cfg.OpenAI.Model)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	clearEnv(t)
+
+	path := writeFile(t, "config.toml", `
+[server]
+addr = ":9090"
+
+[openai]
+maxIterations = 3
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Server.Addr != ":9090" || cfg.OpenAI.MaxIterations != 3 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	clearEnv(t)
+
+	for name, content := range map[string]string{
+		"config.yaml": "server:\n  adress: :9090\n",
+		"config.toml": "[server]\nadress = \":9090\"\n",
+	} {
+		if _, err := Load(writeFile(t, name, content)); err == nil {
+			t.Errorf("%s: expected error for unknown field", name)
+		}
+	}
+}
+
+func TestLoadOTLPHeadersFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer abc, x-team = sports")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if got := cfg.Telemetry.OTLPHeaders["authorization"]; got != "Bearer abc" {
+		t.Errorf("authorization header = %q", got)
+	}
+	if got := cfg.Telemetry.OTLPHeaders["x-team"]; got != "sports" {
+		t.Errorf("x-team header = %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantErr string
+	}{
+		{
+			name:    "service pointing at own port",
+			mutate:  func(c *Config) { c.Services.OddsTrackerURL = "http://localhost:8082" },
+			wantErr: "services.oddstrackerUrl",
+		},
+		{
+			name:    "bad scheme",
+			mutate:  func(c *Config) { c.Services.RotoReaderURL = "ftp://roto" },
+			wantErr: "services.rotoreaderUrl",
+		},
+		{
+			name:    "bad listen address",
+			mutate:  func(c *Config) { c.Server.Addr = "8082" },
+			wantErr: "server.addr",
+		},
+		{
+			name:    "zero iterations",
+			mutate:  func(c *Config) { c.OpenAI.MaxIterations = 0 },
+			wantErr: "openai.maxIterations",
+		},
+		{
+			name:    "otlp exporter without endpoint",
+			mutate:  func(c *Config) { c.Telemetry.Exporter = "otlp" },
+			wantErr: "telemetry.otlpEndpoint",
+		},
+		{
+			name: "sampler ratio out of range",
+			mutate: func(c *Config) {
+				c.Telemetry.Sampler = "traceidratio"
+				c.Telemetry.SamplerArg = 1.5
+			},
+			wantErr: "telemetry.samplerArg",
+		},
+		{
+			name:    "client cert without key",
+			mutate:  func(c *Config) { c.Telemetry.TLS.CertFile = "client.pem" },
+			wantErr: "telemetry.tls.certFile",
+		},
+		{
+			name:    "unknown routing mode",
+			mutate:  func(c *Config) { c.OpenAI.Routing.Mode = "cheapest" },
+			wantErr: "openai.routing.mode",
+		},
+		{
+			name:    "fallback without a model",
+			mutate:  func(c *Config) { c.OpenAI.Fallbacks = []ModelEndpoint{{BaseURL: "http://localhost:11434/v1"}} },
+			wantErr: "openai.fallbacks[0].model",
+		},
+		{
+			name:    "unknown embeddings provider",
+			mutate:  func(c *Config) { c.ToolSelection.Embeddings.Provider = "cohere" },
+			wantErr: "toolSelection.embeddings.provider",
+		},
+		{
+			name:    "unknown timezone",
+			mutate:  func(c *Config) { c.Prompts.Timezone = "Mars/Olympus" },
+			wantErr: "prompts.timezone",
+		},
+		{
+			name:    "missing calendar file",
+			mutate:  func(c *Config) { c.Calendar.File = "/does/not/exist.yaml" },
+			wantErr: "calendar.file",
+		},
+		{
+			name:    "entity min score out of range",
+			mutate:  func(c *Config) { c.Entities.MinScore = 1.5 },
+			wantErr: "entities.minScore",
+		},
+		{
+			name:    "missing workflows file",
+			mutate:  func(c *Config) { c.Workflows.File = "/does/not/exist.yaml" },
+			wantErr: "workflows.file",
+		},
+		{
+			name:    "unknown tool output filter",
+			mutate:  func(c *Config) { c.ToolOutput.Filter = "block" },
+			wantErr: "toolOutput.filter",
+		},
+		{
+			name:    "unknown log level",
+			mutate:  func(c *Config) { c.Logging.Level = "verbose" },
+			wantErr: "logging.level",
+		},
+		{
+			name: "plaintext api key",
+			mutate: func(c *Config) {
+				c.Auth.APIKeys = []APIKeyConfig{{ID: "widget", Hash: "sa_widget"}}
+			},
+			wantErr: "auth.apiKeys[0].hash",
+		},
+		{
+			name: "undefined scope",
+			mutate: func(c *Config) {
+				c.Auth.APIKeys = []APIKeyConfig{{ID: "widget", Hash: "sha256:" + strings.Repeat("0", 64), Scopes: []string{"news"}}}
+			},
+			wantErr: "auth.apiKeys[0].scopes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error mentioning %s, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.OpenAI.APIKey = "sk-secret"
+	cfg.Telemetry.OTLPHeaders = map[string]string{"x-honeycomb-team": "hc-secret"}
+	cfg.OpenAI.Fallbacks = []ModelEndpoint{{Model: "llama3", APIKey: "fb-secret"}}
+
+	redacted := cfg.Redacted()
+	if redacted.OpenAI.APIKey == "sk-secret" {
+		t.Fatal("API key was not redacted")
+	}
+	if redacted.OpenAI.Fallbacks[0].APIKey == "fb-secret" {
+		t.Fatal("fallback API key was not redacted")
+	}
+	if redacted.Telemetry.OTLPHeaders["x-honeycomb-team"] == "hc-secret" {
+		t.Fatal("OTLP header was not redacted")
+	}
+	if cfg.Telemetry.OTLPHeaders["x-honeycomb-team"] != "hc-secret" {
+		t.Fatal("Redacted must not modify the original headers")
+	}
+	if cfg.OpenAI.APIKey != "sk-secret" || cfg.OpenAI.Fallbacks[0].APIKey != "fb-secret" {
+		t.Fatal("Redacted must not modify the original config")
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Flags holds the command-line configuration overrides registered on a flag set
+type Flags struct {
+	fs     *flag.FlagSet
+	path   *string
+	values map[string]*string
+}
+
+// BindFlags registers -config and the per-setting override flags on fs
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		fs:     fs,
+		path:   fs.String("config", "", "YAML or TOML config file (defaults to $SPORTSAGENT_CONFIG)"),
+		values: map[string]*string{},
+	}
+
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		f.values[s.flag] = fs.String(s.flag, "", fmt.Sprintf("%s (overrides %s, $%s)", s.usage, s.key, s.env))
+	}
+
+	return f
+}
+
+// Load builds the configuration with flags explicitly set on the command line taking
+// precedence over the config file and environment, then validates it.
+func (f *Flags) Load() (*Config, error) {
+	cfg, err := load(*f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var setErr error
+	f.fs.Visit(func(fl *flag.Flag) {
+		value, ok := f.values[fl.Name]
+		if !ok || setErr != nil {
+			return
+		}
+		for _, s := range settings {
+			if s.flag == fl.Name {
+				if err := s.set(cfg, *value); err != nil {
+					setErr = fmt.Errorf("config: %s from -%s: %w", s.key, s.flag, err)
+				}
+			}
+		}
+	})
+	if setErr != nil {
+		return nil, setErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
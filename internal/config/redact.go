@@ -0,0 +1,33 @@
+package config
+
+const redactedValue = "[redacted]"
+
+// Redacted returns a copy of the configuration with secrets masked, safe to print or log
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.OpenAI.APIKey = redact(c.OpenAI.APIKey)
+	out.Server.AdminToken = redact(c.Server.AdminToken)
+	if c.OpenAI.Fallbacks != nil {
+		out.OpenAI.Fallbacks = make([]ModelEndpoint, len(c.OpenAI.Fallbacks))
+		for i, fallback := range c.OpenAI.Fallbacks {
+			fallback.APIKey = redact(fallback.APIKey)
+			out.OpenAI.Fallbacks[i] = fallback
+		}
+	}
+
+	// OTLP headers usually carry vendor API keys
+	if c.Telemetry.OTLPHeaders != nil {
+		out.Telemetry.OTLPHeaders = make(map[string]string, len(c.Telemetry.OTLPHeaders))
+		for k, v := range c.Telemetry.OTLPHeaders {
+			out.Telemetry.OTLPHeaders[k] = redact(v)
+		}
+	}
+	return &out
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
@@ -0,0 +1,243 @@
+// Package entities resolves the players and teams named in queries and tool arguments.
+// Services spell names differently ("Pat Mahomes" and "Patrick Mahomes", "KC" and "Kansas
+// City Chiefs"), so names are matched against an alias dictionary, exactly or fuzzily, and
+// can be rewritten to the spelling a given service expects.
+package entities
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin.yaml
+var builtin []byte
+
+// Entity types
+const (
+	Team   = "team"
+	Player = "player"
+)
+
+// Spellings a service can expect for entities
+const (
+	SpellName         = "name"
+	SpellAbbreviation = "abbreviation"
+)
+
+// DefaultMinScore is the fuzzy match similarity needed when none is configured
+const DefaultMinScore = 0.85
+
+// ambiguityMargin is how far ahead of the next entity a fuzzy match must score to be
+// used without asking
+const ambiguityMargin = 0.05
+
+// Entity is a player or team and the names it goes by
+type Entity struct {
+	ID           string `yaml:"id" json:"id"`
+	Type         string `yaml:"type" json:"type"`
+	Name         string `yaml:"name" json:"name"`
+	Abbreviation string `yaml:"abbreviation" json:"abbreviation,omitempty"`
+	League       string `yaml:"league" json:"league,omitempty"`
+	// Team is the id of a player's team
+	Team    string   `yaml:"team" json:"team,omitempty"`
+	Aliases []string `yaml:"aliases" json:"aliases,omitempty"`
+	// Services overrides the spelling for individual services
+	Services map[string]string `yaml:"services" json:"services,omitempty"`
+}
+
+// file is the layout of an alias dictionary
+type file struct {
+	// Spelling is how each service spells entities: name (the default) or abbreviation
+	Spelling map[string]string `yaml:"spelling"`
+	Entities []Entity          `yaml:"entities"`
+}
+
+// Match is an entity that matched a name
+type Match struct {
+	Entity Entity
+	// Score is the similarity of the name to Alias, 1 for an exact match
+	Score float64
+	Alias string
+}
+
+// Resolver matches names against the alias dictionary
+type Resolver struct {
+	minScore float64
+	spelling map[string]string
+	entities map[string]Entity
+	// names are every entity's normalised name, abbreviation and aliases
+	names []name
+}
+
+type name struct {
+	id     string
+	alias  string
+	key    string
+	tokens []string
+	// exactOnly names are too short to match fuzzily, such as abbreviations
+	exactOnly bool
+}
+
+// Load reads the built-in dictionary and the one in path, whose entities replace built-in
+// ones with the same id. Fuzzy matches need at least minScore similarity, from 0 to 1.
+func Load(path string, minScore float64) (*Resolver, error) {
+	r := &Resolver{minScore: minScore, spelling: map[string]string{}, entities: map[string]Entity{}}
+	if err := r.parse(builtin); err != nil {
+		return nil, fmt.Errorf("built-in entities: %w", err)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.parse(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	r.index()
+	return r, nil
+}
+
+func (r *Resolver) parse(data []byte) error {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	for service, spelling := range f.Spelling {
+		if spelling != SpellName && spelling != SpellAbbreviation {
+			return fmt.Errorf("spelling for %s must be %s or %s, got %q", service, SpellName, SpellAbbreviation, spelling)
+		}
+		r.spelling[service] = spelling
+	}
+	for _, e := range f.Entities {
+		if e.ID == "" || e.Name == "" {
+			return errors.New("every entity needs an id and a name")
+		}
+		if e.Type != Team && e.Type != Player {
+			return fmt.Errorf("entity %s: type must be %s or %s, got %q", e.ID, Team, Player, e.Type)
+		}
+		r.entities[e.ID] = e
+	}
+	return nil
+}
+
+// index normalises every name once, in id order so ties resolve the same way every time
+func (r *Resolver) index() {
+	ids := make([]string, 0, len(r.entities))
+	for id := range r.entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	r.names = nil
+	for _, id := range ids {
+		e := r.entities[id]
+		for i, alias := range append([]string{e.Name, e.Abbreviation}, e.Aliases...) {
+			key := normalize(alias)
+			if key == "" {
+				continue
+			}
+			r.names = append(r.names, name{
+				id:        id,
+				alias:     alias,
+				key:       key,
+				tokens:    strings.Fields(key),
+				exactOnly: i == 1 || len(key) <= 3,
+			})
+		}
+	}
+}
+
+// Get returns an entity by id
+func (r *Resolver) Get(id string) (Entity, bool) {
+	e, ok := r.entities[id]
+	return e, ok
+}
+
+// Resolve returns the entities matching text, best first, optionally only those of one
+// type. Exact matches of a name, abbreviation or alias score 1; others need minScore.
+func (r *Resolver) Resolve(text, entityType string, limit int) []Match {
+	key := normalize(text)
+	if key == "" {
+		return nil
+	}
+	tokens := strings.Fields(key)
+	exactOnly := len(key) <= 3
+
+	best := map[string]Match{}
+	for _, n := range r.names {
+		e := r.entities[n.id]
+		if entityType != "" && e.Type != entityType {
+			continue
+		}
+		score := 0.0
+		switch {
+		case n.key == key:
+			score = 1
+		case n.exactOnly || exactOnly:
+			continue
+		default:
+			score = similarity(key, tokens, n.key, n.tokens)
+		}
+		if score < r.minScore || score <= best[n.id].Score {
+			continue
+		}
+		best[n.id] = Match{Entity: e, Score: score, Alias: n.alias}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Entity.ID < matches[j].Entity.ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Identify returns the one entity text names, or false when nothing matches or the best
+// matches are too close to call
+func (r *Resolver) Identify(text, entityType string) (Match, bool) {
+	matches := r.Resolve(text, entityType, 2)
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	if len(matches) > 1 && matches[0].Score-matches[1].Score < ambiguityMargin {
+		return Match{}, false
+	}
+	return matches[0], true
+}
+
+// Spelling returns how service spells an entity: its per-service override, else its
+// abbreviation or name as the service's spelling says
+func (r *Resolver) Spelling(e Entity, service string) string {
+	if s, ok := e.Services[service]; ok && s != "" {
+		return s
+	}
+	if r.spelling[service] == SpellAbbreviation && e.Abbreviation != "" {
+		return e.Abbreviation
+	}
+	return e.Name
+}
+
+// Normalize rewrites a name to service's spelling of the entity it identifies. It returns
+// false, leaving value alone, when the name is unknown or ambiguous.
+func (r *Resolver) Normalize(service, value string) (string, bool) {
+	m, ok := r.Identify(value, "")
+	if !ok {
+		return value, false
+	}
+	return r.Spelling(m.Entity, service), true
+}
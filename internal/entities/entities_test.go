@@ -0,0 +1,145 @@
+package entities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDictionary = `
+spelling:
+  oddstracker: abbreviation
+entities:
+  - id: patrick-mahomes
+    type: player
+    league: nfl
+    team: kc
+    name: Patrick Mahomes II
+    aliases: [Mahomes]
+    services:
+      rotoreader: Pat Mahomes
+  - id: travis-kelce
+    type: player
+    league: nfl
+    team: kc
+    name: Travis Kelce
+  - id: jason-kelce
+    type: player
+    league: nfl
+    team: phi
+    name: Jason Kelce
+`
+
+func loadResolver(t *testing.T) *Resolver {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "entities.yaml")
+	if err := os.WriteFile(path, []byte(testDictionary), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(path, DefaultMinScore)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return r
+}
+
+func TestIdentify(t *testing.T) {
+	r := loadResolver(t)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "KC", want: "kc"},
+		{text: "kan", want: "kc"},
+		{text: "the Chiefs", want: "kc"},
+		{text: "Kansas City Chiefs", want: "kc"},
+		{text: "Kansas Cty Chiefs", want: "kc"},
+		{text: "San Francisco 49ers", want: "sf"},
+		{text: "Patrick Mahomes", want: "patrick-mahomes"},
+		{text: "Pat Mahomes", want: "patrick-mahomes"},
+		{text: "P. Mahomes", want: "patrick-mahomes"},
+		{text: "Patrick Mahómes Jr.", want: "patrick-mahomes"},
+		{text: "mahomes", want: "patrick-mahomes"},
+		{text: "Travis Kelse", want: "travis-kelce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, ok := r.Identify(tt.text, "")
+			if !ok {
+				t.Fatalf("expected %q to identify %s, got %+v", tt.text, tt.want, r.Resolve(tt.text, "", 3))
+			}
+			if m.Entity.ID != tt.want {
+				t.Fatalf("expected %s, got %s (%.2f via %q)", tt.want, m.Entity.ID, m.Score, m.Alias)
+			}
+		})
+	}
+}
+
+func TestIdentify_UnknownOrAmbiguous(t *testing.T) {
+	r := loadResolver(t)
+
+	for _, text := range []string{"Kelce", "New York", "LA", "Toronto Maple Leafs", "K"} {
+		if m, ok := r.Identify(text, ""); ok {
+			t.Errorf("expected %q not to identify an entity, got %s", text, m.Entity.ID)
+		}
+	}
+	if got := r.Resolve("Kelce", Player, 5); len(got) != 2 {
+		t.Errorf("expected both Kelces as candidates, got %+v", got)
+	}
+	if got := r.Resolve("Chiefs", Player, 5); len(got) != 0 {
+		t.Errorf("a type filter should exclude teams, got %+v", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	r := loadResolver(t)
+
+	tests := []struct {
+		service string
+		value   string
+		want    string
+		ok      bool
+	}{
+		{service: "oddstracker", value: "Kansas City Chiefs", want: "KC", ok: true},
+		{service: "rotoreader", value: "KC", want: "Kansas City Chiefs", ok: true},
+		{service: "rotoreader", value: "Patrick Mahomes", want: "Pat Mahomes", ok: true},
+		// Players have no abbreviation, so their name is used
+		{service: "oddstracker", value: "Pat Mahomes", want: "Patrick Mahomes II", ok: true},
+		{service: "oddstracker", value: "Kelce", want: "Kelce", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := r.Normalize(tt.service, tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%s, %q) = %q, %v; want %q, %v", tt.service, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing name":     "entities:\n  - {id: x, type: team}\n",
+		"unknown type":     "entities:\n  - {id: x, type: coach, name: Andy Reid}\n",
+		"unknown spelling": "spelling:\n  oddstracker: nickname\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "entities.yaml")
+			os.WriteFile(path, []byte(data), 0o644)
+			if _, err := Load(path, DefaultMinScore); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	if got := jaroWinkler("martha", "marhta"); got < 0.961 || got > 0.962 {
+		t.Errorf("jaroWinkler(martha, marhta) = %.4f, want 0.9611", got)
+	}
+	if got := jaroWinkler("abc", "xyz"); got != 0 {
+		t.Errorf("jaroWinkler(abc, xyz) = %.4f, want 0", got)
+	}
+}
@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialScore is the similarity of an initial to a name it could abbreviate, "P" to
+// "Patrick"
+const initialScore = 0.9
+
+// surnameScore is the similarity of a single word to a longer name ending in it, "Kelce"
+// to "Travis Kelce"
+const surnameScore = 0.9
+
+// tokenFloor is the lowest similarity at which two words count as the same name
+const tokenFloor = 0.8
+
+// suffixes are dropped from names, which services include inconsistently
+var suffixes = map[string]bool{"jr": true, "sr": true, "ii": true, "iii": true, "iv": true}
+
+// accents folds the accented letters common in player names
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c", "ý", "y", "ÿ", "y",
+)
+
+// normalize lowercases a name, folds accents, drops punctuation, a leading "the" and
+// generational suffixes, and collapses spaces
+func normalize(s string) string {
+	s = accents.Replace(strings.ToLower(s))
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	words := fields[:0]
+	for i, field := range fields {
+		field = strings.ReplaceAll(field, "'", "")
+		if field == "" || (i == 0 && field == "the" && len(fields) > 1) || (i > 0 && suffixes[field]) {
+			continue
+		}
+		words = append(words, field)
+	}
+	return strings.Join(words, " ")
+}
+
+// similarity scores two normalised names from 0 to 1: the better of their Jaro-Winkler
+// similarity and how well their words pair up, so reordered names and initials still
+// match, and a surname alone matches the full name
+func similarity(a string, aTokens []string, b string, bTokens []string) float64 {
+	score := max(jaroWinkler(a, b), tokenSimilarity(aTokens, bTokens))
+	if len(aTokens) == 1 && len(bTokens) > 1 && aTokens[0] == bTokens[len(bTokens)-1] {
+		score = max(score, surnameScore)
+	}
+	return score
+}
+
+// tokenSimilarity pairs each word of a with its most similar word of b, averaging over
+// the longer name so missing words count against the match
+func tokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	used := make([]bool, len(b))
+	total := 0.0
+	for _, word := range a {
+		best, bestAt := 0.0, -1
+		for j, other := range b {
+			if used[j] {
+				continue
+			}
+			if score := wordSimilarity(word, other); score > best {
+				best, bestAt = score, j
+			}
+		}
+		if bestAt >= 0 && best >= tokenFloor {
+			used[bestAt] = true
+			total += best
+		}
+	}
+	return total / float64(max(len(a), len(b)))
+}
+
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if (len(a) == 1 && strings.HasPrefix(b, a)) || (len(b) == 1 && strings.HasPrefix(a, b)) {
+		return initialScore
+	}
+	return jaroWinkler(a, b)
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, which favours strings
+// sharing a prefix, as nicknames and misspelt names usually do
+func jaroWinkler(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	if len(ar) == 0 || len(br) == 0 {
+		return 0
+	}
+	window := max(len(ar), len(br))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	aMatched := make([]bool, len(ar))
+	bMatched := make([]bool, len(br))
+	matches := 0
+	for i := range ar {
+		for j := max(0, i-window); j < min(len(br), i+window+1); j++ {
+			if !bMatched[j] && ar[i] == br[j] {
+				aMatched[i], bMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ar {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if ar[i] != br[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ar)) + m/float64(len(br)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ar), len(br)) && ar[prefix] == br[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
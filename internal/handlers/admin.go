@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sportsagent/internal/logging"
+	"sportsagent/internal/services"
+	"strings"
+)
+
+// AdminHandler serves operator endpoints guarded by a shared bearer token
+type AdminHandler struct {
+	agentService *services.AgentService
+	token        string
+}
+
+func NewAdminHandler(agentService *services.AgentService, token string) *AdminHandler {
+	return &AdminHandler{
+		agentService: agentService,
+		token:        token,
+	}
+}
+
+// HandleUsage reports today's token usage and estimated cost per API key
+func (h *AdminHandler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.agentService.Usage())
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// HandleLogLevel reports the log level on GET and changes it on PUT, e.g. to switch on
+// debug logging of full queries and tool payloads while investigating an issue
+func (h *AdminHandler) HandleLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var req LogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			http.Error(w, "level must be debug, info, warn or error", http.StatusBadRequest)
+			return
+		}
+		previous := logging.Level.Level()
+		logging.Level.Set(level)
+		slog.WarnContext(r.Context(), "log level changed", "from", previous.String(), "to", level.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LogLevelResponse{Level: logging.Level.Level().String()})
+}
+
+func (h *AdminHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sportsagent/internal/auth"
+	"sportsagent/internal/config"
+	"sportsagent/internal/logging"
+	"sportsagent/internal/services"
+	"sportsagent/internal/usage"
+)
+
+func TestHandleUsageRequiresToken(t *testing.T) {
+	handler := NewAdminHandler(services.NewAgentService(config.Default()), "s3cret")
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"valid", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.HandleUsage(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var report usage.Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode report: %v", err)
+			}
+			if report.Day == "" {
+				t.Fatal("expected report day to be set")
+			}
+		})
+	}
+}
+
+func TestCallerKeyIgnoresUnauthenticatedKeys(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("X-API-Key", "sk-live-123")
+	if got := callerKey(req); got != services.AnonymousCaller {
+		t.Fatalf("expected anonymous caller, got %q", got)
+	}
+
+	// With authentication disabled the middleware identifies clients by address
+	anonymous := &auth.Client{ID: "ip:192.0.2.1", Method: auth.MethodAnonymous}
+	for _, key := range []string{"sk-live-123", "sk-live-456"} {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil).WithContext(auth.WithClient(context.Background(), anonymous))
+		req.Header.Set("X-API-Key", key)
+		if got := callerKey(req); got != anonymous.ID {
+			t.Fatalf("expected %q for key %s, got %q", anonymous.ID, key, got)
+		}
+	}
+}
+
+func TestHandleLogLevel(t *testing.T) {
+	previous := logging.Level.Level()
+	t.Cleanup(func() { logging.Level.Set(previous) })
+	handler := NewAdminHandler(services.NewAgentService(config.Default()), "s3cret")
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	handler.HandleLogLevel(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if logging.Level.Level() != slog.LevelDebug {
+		t.Fatalf("expected debug level, got %s", logging.Level.Level())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"loud"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	handler.HandleLogLevel(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown level, got %d", w.Code)
+	}
+}
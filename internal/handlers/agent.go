@@ -2,27 +2,71 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"math"
 	"net/http"
+	"sportsagent/internal/auth"
+	"sportsagent/internal/prompts"
+	"sportsagent/internal/schemas"
 	"sportsagent/internal/services"
+	"sportsagent/internal/usage"
+	"strconv"
 )
 
 type AgentHandler struct {
 	agentService *services.AgentService
 }
 
-func NewAgentHandler() *AgentHandler {
+func NewAgentHandler(agentService *services.AgentService) *AgentHandler {
 	return &AgentHandler{
-		agentService: services.NewAgentService(),
+		agentService: agentService,
 	}
 }
 
 type QueryRequest struct {
 	Query string `json:"query"`
+	// Prompt selects a system prompt template by name
+	Prompt    string            `json:"prompt,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	// Schema is a JSON Schema for a structured answer; SchemaName selects a registered one
+	Schema     json.RawMessage `json:"schema,omitempty"`
+	SchemaName string          `json:"schemaName,omitempty"`
+	// IncludeSteps returns each model round trip and tool call; Debug is an alias
+	IncludeSteps bool `json:"includeSteps,omitempty"`
+	Debug        bool `json:"debug,omitempty"`
 }
 
+// Query statuses
+const (
+	StatusCompleted       = "completed"
+	StatusPendingApproval = "pending_approval"
+)
+
 type QueryResponse struct {
-	Response string `json:"response"`
+	Status   string      `json:"status"`
+	Response string      `json:"response"`
+	Usage    usage.Usage `json:"usage"`
+	// Model answered the query, after routing and any fallback; Route is how it was chosen
+	Model    string                    `json:"model"`
+	Route    string                    `json:"route,omitempty"`
+	Approval *services.PendingApproval `json:"approval,omitempty"`
+	// Data is the answer as JSON matching the requested schema
+	Data json.RawMessage `json:"data,omitempty"`
+	// Sources are the tool calls behind the answer; their labels are cited in it
+	Sources []services.Source `json:"sources,omitempty"`
+	Steps   []services.Step   `json:"steps,omitempty"`
+}
+
+// ErrorResponse is written instead of a plain-text error when the request asked for steps
+type ErrorResponse struct {
+	Error string          `json:"error"`
+	Steps []services.Step `json:"steps"`
+}
+
+// ApprovalRequest is the decision for a query paused on tool calls with side effects
+type ApprovalRequest struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 func (h *AgentHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
@@ -36,13 +80,150 @@ func (h *AgentHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Println("Received query:", req.Query)
-	response, err := h.agentService.ProcessQuery(r.Context(), req.Query)
+	schema, err := h.requestSchema(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.agentService.Run(r.Context(), services.NewConversation(), services.Request{
+		Query:        req.Query,
+		Caller:       callerKey(r),
+		Access:       toolAccess(h.agentService, r),
+		Prompt:       req.Prompt,
+		Variables:    req.Variables,
+		Schema:       schema,
+		IncludeSteps: req.IncludeSteps || req.Debug,
+	})
+	if errors.Is(err, prompts.ErrUnknownTemplate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeResult(w, result, err)
+}
+
+// requestSchema resolves the schema for a structured answer, if one was requested
+func (h *AgentHandler) requestSchema(req QueryRequest) (*schemas.Schema, error) {
+	switch {
+	case len(req.Schema) > 0 && req.SchemaName != "":
+		return nil, errors.New("set either schema or schemaName, not both")
+	case len(req.Schema) > 0:
+		return schemas.Parse("response", req.Schema)
+	case req.SchemaName != "":
+		return h.agentService.Schema(req.SchemaName)
+	default:
+		return nil, nil
+	}
+}
+
+// HandleApproval approves or rejects the tool calls of a paused query and continues it.
+// Only the caller that made the query can decide.
+func (h *AgentHandler) HandleApproval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := h.agentService.Resume(r.Context(), r.PathValue("id"), callerKey(r),
+		services.Decision{Approved: req.Approved, Reason: req.Reason}, nil)
+	if errors.Is(err, services.ErrApprovalNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeResult(w, result, err)
+}
+
+// HandleSource returns the raw backend payload behind a cited source, for auditing.
+// Only the caller whose query recorded the source can read it.
+func (h *AgentHandler) HandleSource(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := h.agentService.Source(r.PathValue("id"), callerKey(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-	log.Println("Sending response:", response)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(QueryResponse{Response: response})
+	json.NewEncoder(w).Encode(payload)
+}
+
+// writeResult writes the outcome of an agent turn. Turns waiting for approval are
+// reported with 202 Accepted.
+func writeResult(w http.ResponseWriter, result *services.Result, err error) {
+	if err != nil {
+		writeTurnError(w, err)
+		return
+	}
+
+	response := QueryResponse{
+		Status:   StatusCompleted,
+		Response: result.Response,
+		Model:    result.Model,
+		Route:    result.Route,
+		Usage:    result.Usage,
+		Data:     result.Data,
+		Sources:  result.Sources,
+		Steps:    result.Steps,
+	}
+	status := http.StatusOK
+	if result.Pending != nil {
+		response.Status = StatusPendingApproval
+		response.Approval = result.Pending
+		status = http.StatusAccepted
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeTurnError writes a failed agent turn, with its steps if the request asked for them
+func writeTurnError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var budgetErr *usage.BudgetError
+	switch {
+	case errors.As(err, &budgetErr):
+		if budgetErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(budgetErr.RetryAfter.Seconds()))))
+		}
+		status = http.StatusTooManyRequests
+	case errors.Is(err, schemas.ErrNonConforming):
+		status = http.StatusBadGateway
+	}
+
+	var turnErr *services.TurnError
+	if !errors.As(err, &turnErr) {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: turnErr.Err.Error(), Steps: turnErr.Steps})
+}
+
+// toolAccess returns the tools the authenticated client may use. Requests that did not
+// pass through the auth middleware are trusted with every tool.
+func toolAccess(agentService *services.AgentService, r *http.Request) *auth.ToolAccess {
+	client, ok := auth.ClientFrom(r.Context())
+	if !ok {
+		return nil
+	}
+	return agentService.ToolAccess(client.Scopes)
+}
+
+// callerKey identifies who a request is charged to: the client set by the auth middleware,
+// which is the remote address with authentication disabled. Presented keys are not trusted
+// until they are authenticated, so sending a new one does not reset budgets or approvals.
+func callerKey(r *http.Request) string {
+	if client, ok := auth.ClientFrom(r.Context()); ok {
+		return client.ID
+	}
+	return services.AnonymousCaller
 }
@@ -7,6 +7,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"sportsagent/internal/config"
+	"sportsagent/internal/services"
+
 	"github.com/joho/godotenv"
 )
 
@@ -15,7 +18,11 @@ func init() {
 }
 
 func TestHandleQuery_Success(t *testing.T) {
-	handler := NewAgentHandler()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	handler := NewAgentHandler(services.NewAgentService(cfg))
 
 	reqBody := QueryRequest{Query: "What's the latest sports news?"}
 	body, _ := json.Marshal(reqBody)
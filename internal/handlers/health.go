@@ -3,7 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
+	"time"
 
+	"sportsagent/internal/health"
 	"sportsagent/internal/version"
 )
 
@@ -12,8 +15,35 @@ type healthResponse struct {
 	Version string `json:"version,omitempty"`
 }
 
-// HandleHealth returns a static response so infrastructure can verify the process is running.
-func HandleHealth(w http.ResponseWriter, r *http.Request) {
+type readinessResponse struct {
+	Status    string                   `json:"status"`
+	Version   string                   `json:"version,omitempty"`
+	CheckedAt time.Time                `json:"checkedAt,omitempty"`
+	Checks    map[string]health.Result `json:"checks,omitempty"`
+}
+
+// HealthHandler serves liveness and readiness probes. Readiness combines the dependency
+// checker with a draining flag that is flipped during shutdown so load balancers stop
+// routing new requests.
+type HealthHandler struct {
+	ready   atomic.Bool
+	checker *health.Checker
+}
+
+// NewHealthHandler creates a handler that starts ready. A nil checker skips dependency checks.
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	h := &HealthHandler{checker: checker}
+	h.ready.Store(true)
+	return h
+}
+
+// SetReady marks the instance as ready or draining
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// HandleLivez returns a static response so infrastructure can verify the process is running.
+func (h *HealthHandler) HandleLivez(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -22,3 +52,35 @@ func HandleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(healthResponse{Status: "ok", Version: version.Version})
 }
+
+// HandleReadyz reports whether the instance should receive traffic, with a per-dependency
+// breakdown. It returns 503 while draining or when any dependency check fails.
+func (h *HealthHandler) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !h.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readinessResponse{Status: "draining", Version: version.Version})
+		return
+	}
+
+	if h.checker == nil {
+		json.NewEncoder(w).Encode(readinessResponse{Status: health.StatusOK, Version: version.Version})
+		return
+	}
+
+	report := h.checker.Check(r.Context())
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(readinessResponse{
+		Status:    report.Status,
+		Version:   version.Version,
+		CheckedAt: report.CheckedAt,
+		Checks:    report.Checks,
+	})
+}
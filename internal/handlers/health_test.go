@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sportsagent/internal/health"
+)
+
+func TestHandleReadyz_FailingDependency(t *testing.T) {
+	checker := health.NewChecker(0, time.Second,
+		health.Check{Name: "rotoreader", Probe: func(ctx context.Context) (string, error) { return "", errors.New("connection refused") }},
+		health.Check{Name: "llm", Probe: func(ctx context.Context) (string, error) { return "", nil }},
+	)
+	handler := NewHealthHandler(checker)
+
+	w := httptest.NewRecorder()
+	handler.HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	var resp readinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Checks["rotoreader"].Status != health.StatusFail || resp.Checks["llm"].Status != health.StatusOK {
+		t.Fatalf("unexpected check breakdown: %+v", resp.Checks)
+	}
+}
+
+func TestHandleReadyz_Draining(t *testing.T) {
+	handler := NewHealthHandler(nil)
+	handler.SetReady(false)
+
+	w := httptest.NewRecorder()
+	handler.HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleLivez(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("liveness should stay ok while draining, got %d", w.Code)
+	}
+}
@@ -2,20 +2,30 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sportsagent/internal/services"
 	"sportsagent/internal/tools"
 )
 
 type ToolsHandler struct {
-	tools []interface{}
+	agentService *services.AgentService
+	tools        []interface{}
+	names        []string
 }
 
-func NewToolsHandler() *ToolsHandler {
+func NewToolsHandler(agentService *services.AgentService) *ToolsHandler {
 	// Get the tools and convert to a serializable format
-	rawTools := tools.GetTools()
+	rawTools := agentService.Tools()
 	serializedTools := make([]interface{}, len(rawTools))
+	names := make([]string, len(rawTools))
 
 	for i, tool := range rawTools {
+		if fn := tool.GetFunction(); fn != nil {
+			names[i] = fn.Name
+		}
+
 		// Convert to map for JSON serialization
 		toolBytes, _ := json.Marshal(tool)
 		var toolMap map[string]interface{}
@@ -24,7 +34,9 @@ func NewToolsHandler() *ToolsHandler {
 	}
 
 	return &ToolsHandler{
-		tools: serializedTools,
+		agentService: agentService,
+		tools:        serializedTools,
+		names:        names,
 	}
 }
 
@@ -33,17 +45,75 @@ type ToolsResponse struct {
 	Count int           `json:"count"`
 }
 
+type InvokeToolRequest struct {
+	Arguments map[string]interface{} `json:"arguments"`
+	DryRun    bool                   `json:"dryRun"`
+}
+
 func (h *ToolsHandler) HandleGetTools(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	access := toolAccess(h.agentService, r)
+	visible := []interface{}{}
+	for i, tool := range h.tools {
+		if access.Allows(h.names[i]) {
+			visible = append(visible, tool)
+		}
+	}
+
 	response := ToolsResponse{
-		Tools: h.tools,
-		Count: len(h.tools),
+		Tools: visible,
+		Count: len(visible),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// HandleInvokeTool calls a tool directly with caller-supplied arguments, bypassing the model,
+// and returns the constructed backend request along with the backend response. Tools the
+// agent would ask approval for only run for clients with a scope that names them.
+func (h *ToolsHandler) HandleInvokeTool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req InvokeToolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := r.PathValue("name")
+	access := toolAccess(h.agentService, r)
+	if !access.Allows(name) {
+		http.Error(w, fmt.Sprintf("%v: %s", services.ErrToolNotPermitted, name), http.StatusForbidden)
+		return
+	}
+	// There is no one to approve a direct call, so tools with side effects need a scope
+	// that names them rather than "*"
+	if !req.DryRun && h.agentService.RequiresApproval(name) && !access.AllowsExplicitly(name) {
+		http.Error(w, fmt.Sprintf("%v: %s; use dryRun or a scope that names the tool", services.ErrApprovalRequired, name), http.StatusForbidden)
+		return
+	}
+
+	invocation, err := h.agentService.InspectToolCall(r.Context(), name, req.Arguments, req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownTool):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, tools.ErrInvalidArguments):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invocation)
+}
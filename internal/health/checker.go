@@ -0,0 +1,177 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Check probes a single dependency. Returning a *DegradedError marks the dependency as
+// degraded, which is reported but does not fail readiness; any other error fails it.
+type Check struct {
+	Name  string
+	Probe func(ctx context.Context) (detail string, err error)
+}
+
+// DegradedError reports a dependency that works but not as intended
+type DegradedError struct {
+	Reason string
+}
+
+func (e *DegradedError) Error() string {
+	return e.Reason
+}
+
+type Result struct {
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checkedAt"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Ready reports whether no check failed
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Checker runs dependency checks concurrently and caches the report for ttl so probes
+// from the orchestrator do not hammer the backends.
+type Checker struct {
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+func NewChecker(ttl, timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		ttl:     ttl,
+		timeout: timeout,
+	}
+}
+
+// Check returns the cached report if it is still fresh, otherwise runs every check.
+// Probes run outside the lock, so a slow dependency does not hold up other callers, and a
+// report cut short by the caller's context being cancelled is not cached.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	cached := c.cached
+	c.mu.Unlock()
+	if cached != nil && time.Since(cached.CheckedAt) < c.ttl {
+		return *cached
+	}
+
+	report := c.runChecks(ctx)
+	if ctx.Err() != nil {
+		return report
+	}
+
+	c.mu.Lock()
+	if c.cached == nil || c.cached.CheckedAt.Before(report.CheckedAt) {
+		c.cached = &report
+	}
+	c.mu.Unlock()
+	return report
+}
+
+// runChecks probes every dependency concurrently under the check timeout
+func (c *Checker) runChecks(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]Result, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := run(ctx, check)
+
+			resultsMu.Lock()
+			report.Checks[check.Name] = result
+			resultsMu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusFail:
+			report.Status = StatusFail
+		case result.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check Check) Result {
+	start := time.Now()
+	detail, err := check.Probe(ctx)
+	result := Result{
+		Status:    StatusOK,
+		Detail:    detail,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		var degraded *DegradedError
+		if errors.As(err, &degraded) {
+			result.Status = StatusDegraded
+		} else {
+			result.Status = StatusFail
+		}
+	}
+
+	return result
+}
+
+// HTTPCheck probes a URL and fails unless it answers with a 2xx status
+func HTTPCheck(name, url string, client *http.Client) Check {
+	return Check{
+		Name: name,
+		Probe: func(ctx context.Context) (string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return url, err
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return url, err
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return url, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+			}
+			return url, nil
+		},
+	}
+}
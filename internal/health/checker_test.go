@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func staticCheck(name string, err error) Check {
+	return Check{
+		Name:  name,
+		Probe: func(ctx context.Context) (string, error) { return "", err },
+	}
+}
+
+func TestCheckerAggregatesStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+		ready  bool
+	}{
+		{
+			name:   "all ok",
+			checks: []Check{staticCheck("a", nil), staticCheck("b", nil)},
+			want:   StatusOK,
+			ready:  true,
+		},
+		{
+			name:   "degraded does not fail readiness",
+			checks: []Check{staticCheck("a", nil), staticCheck("b", &DegradedError{Reason: "fallback"})},
+			want:   StatusDegraded,
+			ready:  true,
+		},
+		{
+			name:   "failure wins",
+			checks: []Check{staticCheck("a", errors.New("down")), staticCheck("b", &DegradedError{Reason: "fallback"})},
+			want:   StatusFail,
+			ready:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(0, time.Second, tt.checks...).Check(context.Background())
+
+			if report.Status != tt.want || report.Ready() != tt.ready {
+				t.Fatalf("got status %s ready %t, want %s ready %t", report.Status, report.Ready(), tt.want, tt.ready)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("expected %d check results, got %d", len(tt.checks), len(report.Checks))
+			}
+		})
+	}
+}
+
+func TestCheckerCachesReport(t *testing.T) {
+	var probes atomic.Int32
+	check := Check{
+		Name: "counted",
+		Probe: func(ctx context.Context) (string, error) {
+			probes.Add(1)
+			return "", nil
+		},
+	}
+
+	checker := NewChecker(time.Minute, time.Second, check)
+	checker.Check(context.Background())
+	checker.Check(context.Background())
+
+	if probes.Load() != 1 {
+		t.Fatalf("expected cached report to be reused, got %d probes", probes.Load())
+	}
+}
+
+func TestCheckerDoesNotCacheCancelledProbes(t *testing.T) {
+	var probes atomic.Int32
+	check := Check{
+		Name: "cancellable",
+		Probe: func(ctx context.Context) (string, error) {
+			probes.Add(1)
+			return "", ctx.Err()
+		},
+	}
+
+	checker := NewChecker(time.Minute, time.Second, check)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := checker.Check(cancelled); report.Ready() {
+		t.Fatal("expected the cancelled probe to fail")
+	}
+
+	if report := checker.Check(context.Background()); !report.Ready() || probes.Load() != 2 {
+		t.Fatalf("expected a fresh probe after a cancelled one, got %s after %d probes", report.Status, probes.Load())
+	}
+}
+
+func TestCheckerDoesNotBlockOnSlowProbes(t *testing.T) {
+	release := make(chan struct{})
+	var probes atomic.Int32
+	check := Check{
+		Name: "slow once",
+		Probe: func(ctx context.Context) (string, error) {
+			if probes.Add(1) == 1 {
+				<-release
+			}
+			return "", nil
+		},
+	}
+
+	checker := NewChecker(time.Minute, time.Minute, check)
+	done := make(chan struct{})
+	go func() {
+		checker.Check(context.Background())
+		close(done)
+	}()
+	for probes.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	checked := make(chan Report)
+	go func() { checked <- checker.Check(context.Background()) }()
+	select {
+	case report := <-checked:
+		if !report.Ready() {
+			t.Fatalf("unexpected status %s", report.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a slow probe blocked another caller")
+	}
+	close(release)
+	<-done
+}
+
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi.json" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ok := HTTPCheck("up", srv.URL+"/openapi.json", srv.Client())
+	if _, err := ok.Probe(context.Background()); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+
+	missing := HTTPCheck("missing", srv.URL+"/nope", srv.Client())
+	if _, err := missing.Probe(context.Background()); err == nil {
+		t.Fatal("expected probe to fail on 404")
+	}
+}
@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sportsagent/internal/tools"
+)
+
+// ToolSourceCheck reports degraded when tools came from the hardcoded fallback instead
+// of the backends' OpenAPI specs
+func ToolSourceCheck() Check {
+	return Check{
+		Name: "tools",
+		Probe: func(ctx context.Context) (string, error) {
+			source, reason := tools.LoadedSource()
+			switch source {
+			case tools.SourceOpenAPI:
+				return source, nil
+			case tools.SourceFallback:
+				return source, &DegradedError{Reason: fmt.Sprintf("using fallback tool definitions: %s", reason)}
+			default:
+				return "", errors.New("tools have not been loaded")
+			}
+		},
+	}
+}
+
+// CredentialCheck fails when the LLM API key is missing
+func CredentialCheck(name, apiKey string) Check {
+	return Check{
+		Name: name,
+		Probe: func(ctx context.Context) (string, error) {
+			if apiKey == "" {
+				return "", errors.New("no API key configured")
+			}
+			return "API key configured", nil
+		},
+	}
+}
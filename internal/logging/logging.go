@@ -0,0 +1,77 @@
+// Package logging configures the process-wide slog logger. Every record logged with a
+// context carries the request ID and, when a span is active, the trace and span IDs.
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"sportsagent/internal/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Level is the minimum level of the default logger. It may be changed at runtime.
+var Level = new(slog.LevelVar)
+
+// Setup installs a JSON or text logger writing to w as the slog default. The standard
+// library log package is routed through it as well.
+func Setup(w io.Writer, cfg config.LoggingConfig) error {
+	if err := Level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: Level}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// contextHandler adds request and trace identifiers from the record context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Content logs user or model content such as a query, a response or a tool payload.
+// Outside debug level only its length and a short hash are recorded, which is enough
+// to correlate repeated values without retaining them.
+func Content(key, value string) slog.Attr {
+	if Level.Level() <= slog.LevelDebug {
+		return slog.String(key, value)
+	}
+	sum := sha256.Sum256([]byte(value))
+	return slog.Group(key,
+		slog.Int("len", len(value)),
+		slog.String("sha256", hex.EncodeToString(sum[:8])),
+	)
+}
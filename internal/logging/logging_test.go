@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sportsagent/internal/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupBuffer installs a JSON logger writing to a buffer and restores the previous
+// default logger and level when the test ends
+func setupBuffer(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+
+	previous, previousLevel := slog.Default(), Level.Level()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		Level.Set(previousLevel)
+	})
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, config.LoggingConfig{Level: level, Format: "json"}); err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+	return &buf
+}
+
+func decodeRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log output is not a single JSON record: %v\n%s", err, buf.String())
+	}
+	return record
+}
+
+func TestContentIsRedactedUnlessDebug(t *testing.T) {
+	buf := setupBuffer(t, "info")
+
+	slog.Info("query", Content("query", "who covers the spread?"))
+	if strings.Contains(buf.String(), "spread") {
+		t.Fatalf("query logged in full at info level: %s", buf.String())
+	}
+	record := decodeRecord(t, buf)
+	query, _ := record["query"].(map[string]any)
+	if query["len"] != float64(len("who covers the spread?")) || query["sha256"] == "" {
+		t.Fatalf("expected length and hash, got %v", record["query"])
+	}
+
+	buf.Reset()
+	Level.Set(slog.LevelDebug)
+	slog.Info("query", Content("query", "who covers the spread?"))
+	if record := decodeRecord(t, buf); record["query"] != "who covers the spread?" {
+		t.Fatalf("expected full query at debug level, got %v", record["query"])
+	}
+}
+
+func TestRecordsCarryRequestAndTraceIDs(t *testing.T) {
+	buf := setupBuffer(t, "info")
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req-1"), sc)
+
+	slog.InfoContext(ctx, "hello")
+
+	record := decodeRecord(t, buf)
+	if record["request_id"] != "req-1" {
+		t.Errorf("request_id = %v", record["request_id"])
+	}
+	if record["trace_id"] != sc.TraceID().String() || record["span_id"] != sc.SpanID().String() {
+		t.Errorf("trace_id = %v, span_id = %v", record["trace_id"], record["span_id"])
+	}
+}
+
+func TestMiddlewareAssignsRequestID(t *testing.T) {
+	setupBuffer(t, "info")
+
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name, header string
+		reused       bool
+	}{
+		{"generated", "", false},
+		{"reused", "abc-123", true},
+		{"rejected", "bad id\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/query", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response header %q does not match context ID %q", got, seen)
+			}
+			if (got == tt.header) != tt.reused {
+				t.Fatalf("header %q reused = %v, want %v", tt.header, got == tt.header, tt.reused)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package metrics defines the Prometheus instruments for the agent. Label values are
+// bounded: models outside the registered set report as "other" and tools without
+// registered metadata report as "unknown", so a hallucinated tool name or a caller
+// supplied model cannot create new series.
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	namespace = "sportsagent"
+
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+
+	// Unknown is the label value for tools and services without registered metadata
+	Unknown = "unknown"
+
+	otherModel = "other"
+)
+
+var (
+	QueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "query_duration_seconds",
+		Help:      "End-to-end latency of agent queries, including every model and tool call.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"outcome"})
+
+	CompletionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_completion_duration_seconds",
+		Help:      "Latency of individual chat completion calls.",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 60},
+	}, []string{"model", "outcome"})
+
+	ToolCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Latency of tool executions by tool, backing service and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool", "service", "status"})
+
+	ToolsOffered = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tools_offered",
+		Help:      "Tools sent to the model per query after scope checks and tool selection.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200},
+	})
+
+	AgentIterations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "agent_iterations_total",
+		Help:      "Model round trips made by the agent loop.",
+	}, []string{"model"})
+
+	ToolErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_errors_total",
+		Help:      "Tool executions that failed before returning a backend response, or returned a non-2xx status.",
+	}, []string{"tool", "service"})
+
+	FallbackToolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fallback_tool_calls_total",
+		Help:      "Tool calls served by the hardcoded fallback definitions instead of OpenAPI-derived tools.",
+	}, []string{"tool"})
+
+	ModelFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_fallbacks_total",
+		Help:      "Completions retried on the next model in the fallback chain after a retryable error, by failed and fallback model.",
+	}, []string{"model", "fallback"})
+
+	Answers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "answers_total",
+		Help:      "Answered queries by the model that produced the answer and how it was chosen (default, requested, simple or complex).",
+	}, []string{"model", "route"})
+
+	Tokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens reported in completion usage, by model and type (prompt or completion).",
+	}, []string{"model", "type"})
+
+	Cost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_cost_usd_total",
+		Help:      "Estimated spend in USD from completion usage and the configured price table, by model.",
+	}, []string{"model"})
+
+	BudgetExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "budget_exceeded_total",
+		Help:      "Queries stopped by a spend budget, by scope (request or daily).",
+	}, []string{"scope"})
+
+	ToolCallsDenied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_denied_total",
+		Help:      "Tool calls rejected because the tool is outside the client's scopes.",
+	}, []string{"tool"})
+
+	Approvals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "approvals_total",
+		Help:      "Tool calls held for human approval and their outcome (requested, approved, rejected or expired).",
+	}, []string{"outcome"})
+
+	StructuredOutputs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "structured_outputs_total",
+		Help:      "Structured answers by whether they matched the requested schema after retrying.",
+	}, []string{"result"})
+
+	InjectionSuspected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "injection_suspected_total",
+		Help:      "Untrusted tool results containing instruction-like text, by detection pattern.",
+	}, []string{"tool", "pattern"})
+
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Requests rejected for missing or invalid credentials, by reason.",
+	}, []string{"reason"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the per-client rate limit, by authentication method.",
+	}, []string{"method"})
+)
+
+var (
+	modelsMu sync.RWMutex
+	models   = map[string]struct{}{}
+)
+
+// RegisterModels adds models that may appear as label values
+func RegisterModels(names ...string) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+
+	for _, name := range names {
+		if name != "" {
+			models[name] = struct{}{}
+		}
+	}
+}
+
+// ModelLabel returns the model name if it is registered, otherwise "other"
+func ModelLabel(model string) string {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+
+	if _, ok := models[model]; ok {
+		return model
+	}
+	return otherModel
+}
+
+// ToolLabel returns the tool name when it is known, otherwise "unknown"
+func ToolLabel(name string, known bool) string {
+	if !known || name == "" {
+		return Unknown
+	}
+	return name
+}
+
+// StatusLabel maps a backend HTTP status code to its class, or "error" when no
+// response was received
+func StatusLabel(statusCode int, err error) string {
+	if err != nil || statusCode == 0 {
+		return OutcomeError
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// Outcome maps an error to the outcome label
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestModelLabelBoundsCardinality(t *testing.T) {
+	RegisterModels("gpt-4o")
+
+	if got := ModelLabel("gpt-4o"); got != "gpt-4o" {
+		t.Errorf("registered model: got %s", got)
+	}
+	if got := ModelLabel("made-up-model"); got != otherModel {
+		t.Errorf("unregistered model: got %s, want %s", got, otherModel)
+	}
+}
+
+func TestStatusLabel(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{status: 200, want: "2xx"},
+		{status: 404, want: "4xx"},
+		{status: 503, want: "5xx"},
+		{status: 0, err: errors.New("connection refused"), want: OutcomeError},
+	}
+
+	for _, tt := range tests {
+		if got := StatusLabel(tt.status, tt.err); got != tt.want {
+			t.Errorf("StatusLabel(%d, %v) = %s, want %s", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package oddsmath does betting arithmetic exactly, with rational numbers rather than
+// floats: converting between American, decimal and fractional odds, implied and no-vig
+// probabilities, parlay prices, expected value and Kelly stakes. Results are rounded
+// only when formatted.
+package oddsmath
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Odds formats
+const (
+	American   = "american"
+	Decimal    = "decimal"
+	Fractional = "fractional"
+)
+
+var (
+	one     = big.NewRat(1, 1)
+	hundred = big.NewRat(100, 1)
+)
+
+// ErrInvalidOdds is returned for odds that cannot be parsed or imply no payout
+var ErrInvalidOdds = errors.New("invalid odds")
+
+// Odds are a price held as exact decimal odds: the total return per unit staked
+type Odds struct {
+	decimal *big.Rat
+}
+
+// Parse reads odds in the given format, or detects it when format is empty: a leading
+// sign is American, a slash is fractional, anything else is decimal
+func Parse(value, format string) (Odds, error) {
+	value = strings.TrimSpace(value)
+	if format == "" {
+		format = Detect(value)
+	}
+
+	switch format {
+	case American:
+		r, ok := new(big.Rat).SetString(strings.TrimPrefix(value, "+"))
+		if !ok {
+			return Odds{}, fmt.Errorf("%w: %q is not a number", ErrInvalidOdds, value)
+		}
+		return FromAmerican(r)
+	case Decimal:
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return Odds{}, fmt.Errorf("%w: %q is not a number", ErrInvalidOdds, value)
+		}
+		return FromDecimal(r)
+	case Fractional:
+		num, den, ok := strings.Cut(value, "/")
+		if !ok {
+			den = "1"
+		}
+		n, okN := new(big.Rat).SetString(strings.TrimSpace(num))
+		d, okD := new(big.Rat).SetString(strings.TrimSpace(den))
+		if !okN || !okD || d.Sign() <= 0 || n.Sign() <= 0 {
+			return Odds{}, fmt.Errorf("%w: %q is not a positive fraction", ErrInvalidOdds, value)
+		}
+		return FromDecimal(new(big.Rat).Add(new(big.Rat).Quo(n, d), one))
+	default:
+		return Odds{}, fmt.Errorf("unknown odds format %q", format)
+	}
+}
+
+// Detect guesses the format of an odds string. Unsigned values of 100 or more are taken
+// as American, since decimal odds that long are rare.
+func Detect(value string) string {
+	switch {
+	case strings.HasPrefix(value, "+"), strings.HasPrefix(value, "-"):
+		return American
+	case strings.Contains(value, "/"):
+		return Fractional
+	}
+	if r, ok := new(big.Rat).SetString(value); ok && r.Cmp(hundred) >= 0 {
+		return American
+	}
+	return Decimal
+}
+
+// FromAmerican converts American odds, which must be at least +100 or at most -100
+func FromAmerican(american *big.Rat) (Odds, error) {
+	switch {
+	case american.Cmp(hundred) >= 0:
+		// +150 returns 150 profit per 100 staked
+		return Odds{decimal: new(big.Rat).Add(one, new(big.Rat).Quo(american, hundred))}, nil
+	case american.Cmp(new(big.Rat).Neg(hundred)) <= 0:
+		// -110 needs 110 staked to profit 100
+		return Odds{decimal: new(big.Rat).Add(one, new(big.Rat).Quo(hundred, new(big.Rat).Neg(american)))}, nil
+	default:
+		return Odds{}, fmt.Errorf("%w: American odds must be at least +100 or at most -100, got %s", ErrInvalidOdds, american.FloatString(2))
+	}
+}
+
+// FromDecimal converts decimal odds, which must be greater than 1
+func FromDecimal(decimal *big.Rat) (Odds, error) {
+	if decimal.Cmp(one) <= 0 {
+		return Odds{}, fmt.Errorf("%w: decimal odds must be greater than 1, got %s", ErrInvalidOdds, decimal.FloatString(4))
+	}
+	return Odds{decimal: new(big.Rat).Set(decimal)}, nil
+}
+
+// FromProbability returns the fair odds for a probability between 0 and 1 exclusive
+func FromProbability(p *big.Rat) (Odds, error) {
+	if p.Sign() <= 0 || p.Cmp(one) >= 0 {
+		return Odds{}, fmt.Errorf("probability must be between 0 and 1, got %s", p.FloatString(4))
+	}
+	return Odds{decimal: new(big.Rat).Inv(p)}, nil
+}
+
+// Decimal returns the decimal odds
+func (o Odds) Decimal() *big.Rat {
+	return new(big.Rat).Set(o.decimal)
+}
+
+// Profit returns the profit per unit staked, which is also the fractional odds
+func (o Odds) Profit() *big.Rat {
+	return new(big.Rat).Sub(o.decimal, one)
+}
+
+// American returns the American odds. Even money is +100.
+func (o Odds) American() *big.Rat {
+	profit := o.Profit()
+	if profit.Cmp(one) >= 0 {
+		return profit.Mul(profit, hundred)
+	}
+	return new(big.Rat).Neg(new(big.Rat).Quo(hundred, profit))
+}
+
+// ImpliedProbability returns 1 / decimal odds, including the bookmaker's margin
+func (o Odds) ImpliedProbability() *big.Rat {
+	return new(big.Rat).Inv(o.decimal)
+}
+
+// Market is the implied probabilities of every outcome of one market
+type Market struct {
+	Implied []*big.Rat
+	// Overround is the sum of the implied probabilities; above 1 is the bookmaker's margin
+	Overround *big.Rat
+}
+
+// NewMarket prices a market from the odds of each of its outcomes
+func NewMarket(outcomes []Odds) (Market, error) {
+	if len(outcomes) < 2 {
+		return Market{}, errors.New("a market needs the odds of at least two outcomes")
+	}
+	m := Market{Overround: new(big.Rat)}
+	for _, odds := range outcomes {
+		p := odds.ImpliedProbability()
+		m.Implied = append(m.Implied, p)
+		m.Overround.Add(m.Overround, p)
+	}
+	return m, nil
+}
+
+// Hold is the share of all money staked that the bookmaker keeps on a balanced book:
+// 1 - 1/overround
+func (m Market) Hold() *big.Rat {
+	return new(big.Rat).Sub(one, new(big.Rat).Inv(m.Overround))
+}
+
+// Fair removes the margin proportionally, returning probabilities that sum to 1
+func (m Market) Fair() []*big.Rat {
+	fair := make([]*big.Rat, len(m.Implied))
+	for i, p := range m.Implied {
+		fair[i] = new(big.Rat).Quo(p, m.Overround)
+	}
+	return fair
+}
+
+// Parlay multiplies the decimal odds of every leg
+func Parlay(legs []Odds) (Odds, error) {
+	if len(legs) < 2 {
+		return Odds{}, errors.New("a parlay needs at least two legs")
+	}
+	price := new(big.Rat).Set(one)
+	for _, leg := range legs {
+		price.Mul(price, leg.decimal)
+	}
+	return Odds{decimal: price}, nil
+}
+
+// ExpectedValue returns the expected profit of staking stake at odds when the outcome
+// has probability p: p * profit - (1 - p) * stake
+func ExpectedValue(odds Odds, p, stake *big.Rat) *big.Rat {
+	win := new(big.Rat).Mul(p, new(big.Rat).Mul(odds.Profit(), stake))
+	lose := new(big.Rat).Mul(new(big.Rat).Sub(one, p), stake)
+	return win.Sub(win, lose)
+}
+
+// Kelly returns the Kelly criterion fraction of bankroll to stake, (b*p - q) / b where b
+// is the profit per unit. It is negative when the bet has no edge.
+func Kelly(odds Odds, p *big.Rat) *big.Rat {
+	b := odds.Profit()
+	q := new(big.Rat).Sub(one, p)
+	edge := new(big.Rat).Sub(new(big.Rat).Mul(b, p), q)
+	return edge.Quo(edge, b)
+}
+
+// Format rounds r to places decimal places, halves away from zero, and trims trailing zeros
+func Format(r *big.Rat, places int) string {
+	s := r.FloatString(places)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// FormatAmerican formats American odds with an explicit sign
+func FormatAmerican(r *big.Rat) string {
+	s := Format(r, 2)
+	if r.Sign() > 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// FormatFractional formats the profit per unit as a reduced fraction, e.g. "5/2"
+func FormatFractional(o Odds) string {
+	profit := o.Profit()
+	return profit.Num().String() + "/" + profit.Denom().String()
+}
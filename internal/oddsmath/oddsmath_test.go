@@ -0,0 +1,120 @@
+package oddsmath
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func mustParse(t *testing.T, value string) Odds {
+	t.Helper()
+	odds, err := Parse(value, "")
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", value, err)
+	}
+	return odds
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value      string
+		american   string
+		decimal    string
+		fractional string
+		implied    string
+	}{
+		{value: "-110", american: "-110", decimal: "1.9091", fractional: "10/11", implied: "0.5238"},
+		{value: "+150", american: "+150", decimal: "2.5", fractional: "3/2", implied: "0.4"},
+		{value: "150", american: "+150", decimal: "2.5", fractional: "3/2", implied: "0.4"},
+		{value: "2.5", american: "+150", decimal: "2.5", fractional: "3/2", implied: "0.4"},
+		{value: "3/2", american: "+150", decimal: "2.5", fractional: "3/2", implied: "0.4"},
+		{value: "2", american: "+100", decimal: "2", fractional: "1/1", implied: "0.5"},
+		{value: "1.5", american: "-200", decimal: "1.5", fractional: "1/2", implied: "0.6667"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			odds := mustParse(t, tt.value)
+			if got := FormatAmerican(odds.American()); got != tt.american {
+				t.Errorf("American = %s, want %s", got, tt.american)
+			}
+			if got := Format(odds.Decimal(), 4); got != tt.decimal {
+				t.Errorf("Decimal = %s, want %s", got, tt.decimal)
+			}
+			if got := FormatFractional(odds); got != tt.fractional {
+				t.Errorf("Fractional = %s, want %s", got, tt.fractional)
+			}
+			if got := Format(odds.ImpliedProbability(), 4); got != tt.implied {
+				t.Errorf("ImpliedProbability = %s, want %s", got, tt.implied)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, value := range []string{"-50", "+99", "1", "0.5", "abc", "0/1", "3/0"} {
+		if _, err := Parse(value, ""); !errors.Is(err, ErrInvalidOdds) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidOdds", value, err)
+		}
+	}
+	if _, err := Parse("2", "moneyline"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestMarket(t *testing.T) {
+	market, err := NewMarket([]Odds{mustParse(t, "-110"), mustParse(t, "-110")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Format(market.Overround, 4); got != "1.0476" {
+		t.Errorf("Overround = %s, want 1.0476", got)
+	}
+	if got := Format(market.Hold(), 4); got != "0.0455" {
+		t.Errorf("Hold = %s, want 0.0455", got)
+	}
+	for _, p := range market.Fair() {
+		if p.Cmp(big.NewRat(1, 2)) != 0 {
+			t.Errorf("fair probability = %s, want exactly 1/2", p.RatString())
+		}
+		fair, _ := FromProbability(p)
+		if got := FormatAmerican(fair.American()); got != "+100" {
+			t.Errorf("fair odds = %s, want +100", got)
+		}
+	}
+
+	if _, err := NewMarket([]Odds{mustParse(t, "-110")}); err == nil {
+		t.Error("expected an error for a one-outcome market")
+	}
+}
+
+func TestParlay(t *testing.T) {
+	parlay, err := Parlay([]Odds{mustParse(t, "-110"), mustParse(t, "-110")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (21/11)^2 = 441/121
+	if parlay.Decimal().Cmp(big.NewRat(441, 121)) != 0 {
+		t.Errorf("Decimal = %s, want 441/121", parlay.Decimal().RatString())
+	}
+	if got := FormatAmerican(parlay.American()); got != "+264.46" {
+		t.Errorf("American = %s, want +264.46", got)
+	}
+}
+
+func TestExpectedValueAndKelly(t *testing.T) {
+	odds := mustParse(t, "+150")
+	p := big.NewRat(45, 100)
+
+	// 0.45 * 150 - 0.55 * 100 = 12.5
+	if got := Format(ExpectedValue(odds, p, big.NewRat(100, 1)), 2); got != "12.5" {
+		t.Errorf("ExpectedValue = %s, want 12.5", got)
+	}
+	// (1.5 * 0.45 - 0.55) / 1.5 = 1/12
+	if got := Kelly(odds, p); got.Cmp(big.NewRat(1, 12)) != 0 {
+		t.Errorf("Kelly = %s, want 1/12", got.RatString())
+	}
+	if got := Kelly(odds, big.NewRat(3, 10)); got.Sign() >= 0 {
+		t.Errorf("Kelly without an edge = %s, want negative", got.RatString())
+	}
+}
@@ -0,0 +1,248 @@
+// Package prompts renders the agent's system prompt from Go templates. Built-in templates
+// can be overridden or extended with files in a directory, which is watched for changes.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/tools"
+)
+
+// ext is the file extension of template files
+const ext = ".tmpl"
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+var ErrUnknownTemplate = errors.New("unknown prompt template")
+
+// Data is passed to every template
+type Data struct {
+	// Now is the current time in the configured timezone
+	Now      time.Time
+	Date     string
+	Weekday  string
+	Time     string
+	Timezone string
+	// Services describes the backends behind the tools, from their OpenAPI info blocks
+	Services []tools.ServiceInfo
+	// Tools names the tools offered for the query, after scopes and tool selection
+	Tools []string
+	// Vars holds caller-supplied variables; missing keys render as empty strings
+	Vars map[string]string
+}
+
+// HasTool reports whether any of the named tools is offered, so templates only mention
+// tools the model can call
+func (d Data) HasTool(names ...string) bool {
+	for _, name := range names {
+		if slices.Contains(d.Tools, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store holds the parsed templates
+type Store struct {
+	dir      string
+	def      string
+	location *time.Location
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+	stamp     string
+	checked   time.Time
+}
+
+// New loads the built-in templates and those in cfg.Dir. The default template must exist.
+func New(cfg config.PromptsConfig) (*Store, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		dir:      cfg.Dir,
+		def:      cfg.Default,
+		location: location,
+		interval: cfg.ReloadInterval,
+		now:      time.Now,
+	}
+
+	stamp, err := s.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := templates[s.def]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, s.def)
+	}
+	s.templates, s.stamp, s.checked = templates, stamp, s.now()
+	return s, nil
+}
+
+// Default returns the name of the template used when a request does not name one
+func (s *Store) Default() string {
+	return s.def
+}
+
+// Names lists the available templates
+func (s *Store) Names() []string {
+	templates := s.current()
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the named template, or the default one when name is empty, for a query
+// offered the named tools
+func (s *Store) Render(name string, vars map[string]string, offered []string) (string, error) {
+	if name == "" {
+		name = s.def
+	}
+	tmpl, ok := s.current()[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	now := s.now().In(s.location)
+	data := Data{
+		Now:      now,
+		Date:     now.Format(time.DateOnly),
+		Weekday:  now.Weekday().String(),
+		Time:     now.Format("15:04"),
+		Timezone: s.location.String(),
+		Services: tools.Services(),
+		Tools:    offered,
+		Vars:     vars,
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// current returns the templates, reloading them first if the directory changed. A reload
+// that fails keeps the previous templates.
+func (s *Store) current() map[string]*template.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir == "" || s.interval <= 0 || s.now().Sub(s.checked) < s.interval {
+		return s.templates
+	}
+	s.checked = s.now()
+
+	stamp, err := s.fingerprint()
+	if err != nil {
+		slog.Error("failed to check prompt templates", "dir", s.dir, "error", err)
+		return s.templates
+	}
+	if stamp == s.stamp {
+		return s.templates
+	}
+
+	templates, err := s.load()
+	if err == nil {
+		if _, ok := templates[s.def]; !ok {
+			err = fmt.Errorf("%w: %s", ErrUnknownTemplate, s.def)
+		}
+	}
+	if err != nil {
+		slog.Error("failed to reload prompt templates, keeping the previous ones", "dir", s.dir, "error", err)
+		return s.templates
+	}
+
+	slog.Info("reloaded prompt templates", "dir", s.dir, "templates", len(templates))
+	s.templates, s.stamp = templates, stamp
+	return s.templates
+}
+
+// load parses the built-in templates, then those in the directory
+func (s *Store) load() (map[string]*template.Template, error) {
+	templates := map[string]*template.Template{}
+	if err := parseDir(templates, builtin, "templates"); err != nil {
+		return nil, err
+	}
+	if s.dir != "" {
+		if err := parseDir(templates, os.DirFS(s.dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func parseDir(templates map[string]*template.Template, fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(dir, entry.Name())))
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("parse prompt %s: %w", entry.Name(), err)
+		}
+		templates[name] = tmpl
+	}
+	return nil
+}
+
+// fingerprint identifies the current contents of the directory, so edits, additions and
+// removals trigger a reload
+func (s *Store) fingerprint() (string, error) {
+	if s.dir == "" {
+		return "", nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d %d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
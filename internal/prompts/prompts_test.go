@@ -0,0 +1,125 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/tools"
+)
+
+func newTestStore(t *testing.T, cfg config.PromptsConfig, now time.Time) *Store {
+	t.Helper()
+
+	if cfg.Default == "" {
+		cfg.Default = "default"
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = "UTC"
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func writeTemplate(t *testing.T, dir, name, text string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name+ext), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderDefault(t *testing.T) {
+	// Unreachable specs register the fallback tools and service descriptions
+	tools.GetTools(tools.SpecSources("http://127.0.0.1:1", "http://127.0.0.1:1"))
+	now := time.Date(2026, time.September, 14, 0, 30, 0, 0, time.UTC)
+	s := newTestStore(t, config.PromptsConfig{Timezone: "America/New_York"}, now)
+
+	got, err := s.Render("", map[string]string{"team": "Kansas City Chiefs"}, []string{"get_odds_data", "resolve_dates", "resolve_entity", "convert_odds"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"Today is Sunday, 2026-09-13 and the time is 20:30 (America/New_York)",
+		"- OddsTracker: Recent betting odds changes",
+		"- RotoReader: Latest sports news feed",
+		"- Built-in tools: Exact odds conversion",
+		"The user follows Kansas City Chiefs.",
+		"with the resolve_dates tool",
+		"with the resolve_entity tool",
+		"use the odds calculator tools",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, got)
+		}
+	}
+
+	// Tools withheld from the query are not mentioned
+	got, err = s.Render("", nil, []string{"get_odds_data"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	for _, unwanted := range []string{"resolve_dates", "resolve_entity", "odds calculator"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %q in prompt:\n%s", unwanted, got)
+		}
+	}
+	if !strings.Contains(got, "Quote odds exactly as the tools return them.") {
+		t.Errorf("expected the odds sentence to stay whole:\n%s", got)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	s := newTestStore(t, config.PromptsConfig{}, time.Now())
+
+	if _, err := s.Render("missing", nil, nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestDirectoryTemplatesAndReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "default", "v1 {{ .Date }}")
+	writeTemplate(t, dir, "brief", "Answer in one sentence. {{ .Vars.missing }}end")
+
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(t, config.PromptsConfig{Dir: dir, ReloadInterval: time.Second}, now)
+
+	if got, _ := s.Render("", nil, nil); got != "v1 2026-10-19" {
+		t.Fatalf("the directory should override the built-in default, got %q", got)
+	}
+	if got, _ := s.Render("brief", nil, nil); got != "Answer in one sentence. end" {
+		t.Fatalf("unexpected brief prompt %q", got)
+	}
+
+	writeTemplate(t, dir, "default", "v2 with a longer body")
+	if got, _ := s.Render("", nil, nil); got != "v1 2026-10-19" {
+		t.Fatalf("templates should not be checked before the interval, got %q", got)
+	}
+
+	s.now = func() time.Time { return now.Add(2 * time.Second) }
+	if got, _ := s.Render("", nil, nil); got != "v2 with a longer body" {
+		t.Fatalf("expected the edited template after the interval, got %q", got)
+	}
+
+	writeTemplate(t, dir, "default", "{{ .Broken")
+	s.now = func() time.Time { return now.Add(4 * time.Second) }
+	if got, _ := s.Render("", nil, nil); got != "v2 with a longer body" {
+		t.Fatalf("a broken template should keep the previous version, got %q", got)
+	}
+}
+
+func TestNewRejectsMissingDefault(t *testing.T) {
+	if _, err := New(config.PromptsConfig{Default: "sports-v2", Timezone: "UTC"}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package schemas holds the JSON Schemas clients can ask structured answers to follow,
+// and validates model output against them.
+package schemas
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ext is the file extension of schema files
+const ext = ".json"
+
+//go:embed builtin/*.json
+var builtin embed.FS
+
+var (
+	ErrUnknownSchema = errors.New("unknown schema")
+	ErrInvalidSchema = errors.New("invalid schema")
+	// ErrNonConforming is returned for output that does not match its schema
+	ErrNonConforming = errors.New("output does not match schema")
+)
+
+// nameRe matches the response format names OpenAI accepts
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Schema is a JSON Schema for a structured answer. The root must be an object.
+type Schema struct {
+	Name string
+	// Definition is the schema as sent to the model
+	Definition map[string]any
+	validator  *openapi3.Schema
+	strict     bool
+}
+
+// Parse reads a JSON Schema document
+func Parse(name string, data []byte) (*Schema, error) {
+	if !nameRe.MatchString(name) {
+		return nil, fmt.Errorf("%w: name %q must be 1-64 letters, digits, _ or -", ErrInvalidSchema, name)
+	}
+
+	var definition map[string]any
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSchema, name, err)
+	}
+	if definition["type"] != "object" {
+		return nil, fmt.Errorf("%w: %s: the root must have type object", ErrInvalidSchema, name)
+	}
+
+	validator := openapi3.NewSchema()
+	if err := validator.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSchema, name, err)
+	}
+
+	return &Schema{Name: name, Definition: definition, validator: validator, strict: strict(definition)}, nil
+}
+
+// Strict reports whether the schema meets the rules for OpenAI strict structured outputs:
+// every object lists all its properties as required and allows no others
+func (s *Schema) Strict() bool {
+	return s.strict
+}
+
+// Description returns the schema's top-level description, if any
+func (s *Schema) Description() string {
+	description, _ := s.Definition["description"].(string)
+	return description
+}
+
+// Validate checks that output is JSON matching the schema and returns it compacted
+func (s *Schema) Validate(output string) (json.RawMessage, error) {
+	var value any
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return nil, fmt.Errorf("%w: not valid JSON: %v", ErrNonConforming, err)
+	}
+	if err := s.validator.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNonConforming, err)
+	}
+	return json.Marshal(value)
+}
+
+func strict(definition map[string]any) bool {
+	if definition["type"] == "object" {
+		properties, _ := definition["properties"].(map[string]any)
+		required, _ := definition["required"].([]any)
+		if definition["additionalProperties"] != false || len(required) != len(properties) {
+			return false
+		}
+		for name, property := range properties {
+			child, ok := property.(map[string]any)
+			if !ok || !slices.Contains(required, any(name)) || !strict(child) {
+				return false
+			}
+		}
+	}
+	if items, ok := definition["items"].(map[string]any); ok && !strict(items) {
+		return false
+	}
+	if anyOf, ok := definition["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if child, ok := option.(map[string]any); !ok || !strict(child) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Registry holds the named schemas: the built-in ones and any in a directory
+type Registry struct {
+	schemas map[string]*Schema
+}
+
+// Load reads the built-in schemas and the <name>.json files in dir, which override
+// built-in schemas of the same name
+func Load(dir string) (*Registry, error) {
+	r := &Registry{schemas: map[string]*Schema{}}
+	if err := r.parseDir(builtin, "builtin"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.parseDir(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) parseDir(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(dir, entry.Name())))
+		if err != nil {
+			return err
+		}
+		schema, err := Parse(strings.TrimSuffix(entry.Name(), ext), data)
+		if err != nil {
+			return err
+		}
+		r.schemas[schema.Name] = schema
+	}
+	return nil
+}
+
+// Get returns a named schema
+func (r *Registry) Get(name string) (*Schema, error) {
+	schema, ok := r.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, name)
+	}
+	return schema, nil
+}
+
+// Names lists the registered schemas
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.schemas))
+	for name := range r.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package schemas
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+		strict  bool
+	}{
+		{"strict", `{"type":"object","properties":{"team":{"type":"string"}},"required":["team"],"additionalProperties":false}`, false, true},
+		{"optional property", `{"type":"object","properties":{"team":{"type":"string"}}}`, false, false},
+		{"loose nested object", `{"type":"object","properties":{"rows":{"type":"array","items":{"type":"object","properties":{"a":{"type":"string"}}}}},"required":["rows"],"additionalProperties":false}`, false, false},
+		{"array root", `{"type":"array","items":{"type":"string"}}`, true, false},
+		{"not json", `{"type":`, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := Parse("response", []byte(tt.schema))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSchema) {
+					t.Fatalf("expected ErrInvalidSchema, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if schema.Strict() != tt.strict {
+				t.Fatalf("Strict() = %v, want %v", schema.Strict(), tt.strict)
+			}
+		})
+	}
+
+	if _, err := Parse("odds table", []byte(`{"type":"object"}`)); !errors.Is(err, ErrInvalidSchema) {
+		t.Fatalf("expected a name with spaces to be rejected, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse("response", []byte(`{"type":"object","properties":{"line":{"type":"number"}},"required":["line"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := schema.Validate("{\n  \"line\": -3.5\n}")
+	if err != nil || string(data) != `{"line":-3.5}` {
+		t.Fatalf("expected compacted JSON, got %s, %v", data, err)
+	}
+	for _, output := range []string{`{"line":"-3.5"}`, `{}`, `Chiefs -3.5`} {
+		if _, err := schema.Validate(output); !errors.Is(err, ErrNonConforming) {
+			t.Errorf("Validate(%q): expected ErrNonConforming, got %v", output, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "odds_table.json"), []byte(`{"type":"object","description":"override"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "standings.json"), []byte(`{"type":"object"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`ignored`), 0o644)
+
+	r, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if names := r.Names(); !slices.Equal(names, []string{"odds_table", "player_list", "standings"}) {
+		t.Fatalf("unexpected schemas %v", names)
+	}
+	if schema, _ := r.Get("odds_table"); schema.Description() != "override" {
+		t.Fatal("a schema in the directory should override the built-in one")
+	}
+	if _, err := r.Get("missing"); !errors.Is(err, ErrUnknownSchema) {
+		t.Fatalf("expected ErrUnknownSchema, got %v", err)
+	}
+}
+
+func TestBuiltinSchemasAreStrict(t *testing.T) {
+	r, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range r.Names() {
+		if schema, _ := r.Get(name); !schema.Strict() {
+			t.Errorf("built-in schema %s should satisfy strict structured outputs", name)
+		}
+	}
+}
@@ -3,112 +3,665 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"time"
 
+	"sportsagent/internal/auth"
+	"sportsagent/internal/calendar"
 	"sportsagent/internal/clients"
+	"sportsagent/internal/config"
+	"sportsagent/internal/entities"
+	"sportsagent/internal/guard"
+	"sportsagent/internal/logging"
+	"sportsagent/internal/metrics"
+	"sportsagent/internal/prompts"
+	"sportsagent/internal/schemas"
 	"sportsagent/internal/tools"
+	"sportsagent/internal/toolsearch"
+	"sportsagent/internal/usage"
+	"sportsagent/internal/workflows"
 
 	"github.com/openai/openai-go/v3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
 type AgentService struct {
-	client      *openai.Client
-	rotoreader  *clients.RotoReaderClient
-	oddstracker *clients.OddsTrackerClient
-	tools       []openai.ChatCompletionToolUnionParam
+	client *openai.Client
+	// clients serve fallback models hosted by another provider
+	clients       map[string]*openai.Client
+	fallbacks     []string
+	routing       config.RoutingConfig
+	rotoreader    *clients.RotoReaderClient
+	oddstracker   *clients.OddsTrackerClient
+	tools         []openai.ChatCompletionToolUnionParam
+	model         string
+	maxIterations int
+	// captureContent records prompts, responses and tool payloads on spans
+	captureContent bool
+	prices         usage.Prices
+	budget         usage.Budget
+	ledger         *usage.Ledger
+	policy         *auth.ToolPolicy
+	// approvalRequired pauses the agent before tool calls with side effects
+	approvalRequired bool
+	approvals        *approvalStore
+	guard            *guard.Guard
+	prompts          *prompts.Store
+	schemas          *schemas.Registry
+	sources          *sourceStore
+	selector         *toolsearch.Selector
+	entities         *entities.Resolver
+	// normalize are the argument names rewritten to each service's spelling of an
+	// entity; nil leaves arguments as the model wrote them
+	normalize []string
 }
 
-func NewAgentService() *AgentService {
-	client := openai.NewClient()
+// AnonymousCaller is the budget key for requests that do not identify a caller
+const AnonymousCaller = "anonymous"
+
+func NewAgentService(cfg *config.Config) *AgentService {
+	var fallbacks []string
+	for _, fallback := range cfg.OpenAI.Fallbacks {
+		fallbacks = append(fallbacks, fallback.Model)
+	}
+	routing := cfg.OpenAI.Routing
+	if routing.Mode == "" {
+		routing.Mode = routingOff
+	}
+
+	metrics.RegisterModels(cfg.OpenAI.Model, routing.SimpleModel, routing.ComplexModel, routing.ClassifierModel)
+	metrics.RegisterModels(fallbacks...)
+
+	store, err := prompts.New(cfg.Prompts)
+	if err != nil {
+		slog.Error("failed to load prompt templates, falling back to the built-in default", "dir", cfg.Prompts.Dir, "error", err)
+		store, _ = prompts.New(config.PromptsConfig{Default: "default", Timezone: cfg.Prompts.Timezone})
+	}
+	registry, err := schemas.Load(cfg.Schemas.Dir)
+	if err != nil {
+		slog.Error("failed to load schemas, using only the built-in ones", "dir", cfg.Schemas.Dir, "error", err)
+		registry, _ = schemas.Load("")
+	}
+
+	cal, err := calendar.Load(cfg.Calendar.File, cfg.Prompts.Timezone)
+	if err != nil {
+		slog.Error("failed to load the league calendar, using only the built-in one", "file", cfg.Calendar.File, "error", err)
+		cal, _ = calendar.Load("", cfg.Prompts.Timezone)
+	}
+	tools.SetCalendar(cal)
+	resolver, err := entities.Load(cfg.Entities.File, cfg.Entities.MinScore)
+	if err != nil {
+		slog.Error("failed to load the entity dictionary, using only the built-in one", "file", cfg.Entities.File, "error", err)
+		resolver, _ = entities.Load("", cfg.Entities.MinScore)
+	}
+	tools.SetEntities(resolver)
+	flows, err := workflows.Load(cfg.Workflows.File)
+	if err != nil {
+		slog.Error("failed to load workflows, registering none", "file", cfg.Workflows.File, "error", err)
+	}
+	tools.SetWorkflows(flows)
+	var normalize []string
+	if cfg.Entities.Normalize {
+		normalize = cfg.Entities.Parameters
+	}
+
+	client := newClient(cfg.OpenAI.BaseURL, cfg.OpenAI.APIKey)
+	defs := tools.GetTools(tools.SpecSources(cfg.Services.RotoReaderURL, cfg.Services.OddsTrackerURL))
+	var embedder toolsearch.Embedder
+	if cfg.ToolSelection.Embeddings.Provider == "openai" {
+		embedder = toolsearch.NewOpenAIEmbedder(client, cfg.ToolSelection.Embeddings.Model)
+	}
 
 	return &AgentService{
-		client:      &client,
-		rotoreader:  clients.NewRotoReaderClient(),
-		oddstracker: clients.NewOddsTrackerClient(),
-		tools:       tools.GetTools(),
+		client:         client,
+		clients:        modelClients(cfg.OpenAI),
+		fallbacks:      fallbacks,
+		routing:        routing,
+		rotoreader:     clients.NewRotoReaderClient(cfg.Services.RotoReaderURL),
+		oddstracker:    clients.NewOddsTrackerClient(cfg.Services.OddsTrackerURL),
+		tools:          defs,
+		model:          cfg.OpenAI.Model,
+		maxIterations:  cfg.OpenAI.MaxIterations,
+		captureContent: cfg.Telemetry.CaptureContent,
+		prices:         usage.Prices(cfg.Usage.Prices),
+		budget:         usage.NewBudget(cfg.Usage),
+		ledger:         usage.NewLedger(),
+		policy:         auth.NewToolPolicy(cfg.Auth.Scopes),
+
+		approvalRequired: cfg.Approval.Required,
+		approvals:        newApprovalStore(cfg.Approval.TTL),
+		guard:            guard.New(cfg.ToolOutput),
+		prompts:          store,
+		schemas:          registry,
+		sources:          newSourceStore(cfg.Sources),
+		selector:         toolsearch.New(cfg.ToolSelection, toolsearch.Documents(defs), embedder),
+		entities:         resolver,
+		normalize:        normalize,
 	}
 }
 
-func (s *AgentService) ProcessQuery(ctx context.Context, query string) (string, error) {
-	log.Printf("AgentService: processing query (len=%d)", len(query))
+// Request describes a single user turn handled by the agent
+type Request struct {
+	Query string
+	// Model overrides the service default model and routing when set
+	Model string
+	// Caller is the API key identity charged against the daily budget
+	Caller string
+	// Access limits the tools offered to the model and executed; nil allows every tool
+	Access *auth.ToolAccess
+	// Prompt names the system prompt template; empty uses the configured default
+	Prompt string
+	// Variables are exposed to the prompt template as .Vars
+	Variables map[string]string
+	// Schema asks for the answer to also be returned as JSON matching it
+	Schema *schemas.Schema
+	// IncludeSteps returns every model round trip and tool call in the result, or in a
+	// TurnError if the query fails
+	IncludeSteps bool
+	Hooks        *Hooks
+}
 
-	messages := []openai.ChatCompletionMessageParamUnion{
-		openai.UserMessage(query),
-	}
+// Result is the outcome of a single user turn
+type Result struct {
+	Response string
+	// Model is the model that produced the answer, after routing and any fallback
+	Model string
+	// Route is how the model was chosen: default, requested, simple or complex
+	Route string
+	// Usage sums token usage and cost across every completion in the turn
+	Usage usage.Usage
+	// Pending is set instead of Response when the agent is waiting for approval of a
+	// tool call; continue with Resume
+	Pending *PendingApproval
+	// Data is the answer as JSON matching Request.Schema
+	Data json.RawMessage
+	// Sources are the tool calls made for the answer, with the claims citing them
+	Sources []Source
+	// Steps is set when the request asked for them
+	Steps []Step
+}
+
+// Model returns the default model used for completions
+func (s *AgentService) Model() string {
+	return s.model
+}
 
-	response, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model:    openai.ChatModelGPT4o,
-		Messages: messages,
-		Tools:    s.tools,
-	})
+func (s *AgentService) ProcessQuery(ctx context.Context, query string) (string, error) {
+	result, err := s.Run(ctx, NewConversation(), Request{Query: query})
 	if err != nil {
-		log.Printf("AgentService: chat completion error: %v", err)
 		return "", err
 	}
+	if result.Pending != nil {
+		for _, call := range result.Pending.ToolCalls {
+			if call.RequiresApproval {
+				return "", fmt.Errorf("%w: %s (%s %s)", ErrApprovalRequired, call.Tool, call.Method, call.Path)
+			}
+		}
+	}
+	return result.Response, nil
+}
 
-	choice := response.Choices[0]
-	log.Printf("AgentService: received completion (finishReason=%s, toolCalls=%d)", choice.FinishReason, len(choice.Message.ToolCalls))
+// Run appends the query to the conversation and lets the model call tools until it
+// produces a final answer, or pauses when a tool call needs approval. The conversation
+// is only updated once the turn completes.
+func (s *AgentService) Run(ctx context.Context, conv *Conversation, req Request) (result *Result, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.QueryDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	}()
 
-	if choice.FinishReason == "tool_calls" {
-		for _, toolCall := range choice.Message.ToolCalls {
-			log.Printf("AgentService: handling tool call id=%s type=%s", toolCall.ID, toolCall.Type)
-			result := s.executeToolCall(ctx, toolCall)
+	slog.InfoContext(ctx, "processing query", logging.Content("query", req.Query), "history", len(conv.Messages))
+
+	model, route := req.Model, RouteRequested
+	if model == "" {
+		model, route = s.model, RouteDefault
+	}
+
+	ctx, span := startAgentSpan(ctx, model)
+	defer func() { endSpan(span, err) }()
+
+	caller := req.Caller
+	if caller == "" {
+		caller = AnonymousCaller
+	}
+
+	t := &turn{
+		conv:        conv,
+		req:         req,
+		model:       model,
+		caller:      caller,
+		messages:    append(conv.Messages[:len(conv.Messages):len(conv.Messages)], openai.UserMessage(req.Query)),
+		untrusted:   conv.Untrusted,
+		sourceCount: conv.SourceCount,
+		route:       route,
+	}
+	if req.Model == "" {
+		s.route(ctx, t)
+		span.SetAttributes(semconv.GenAIRequestModel(t.model))
+	}
+	s.selectTools(ctx, t)
 
-			messages = append(messages, choice.Message.ToParam())
-			messages = append(messages, openai.ToolMessage(result, toolCall.ID))
+	// The prompt is rendered once so the date stays fixed for the whole turn, and only
+	// mentions the tools offered
+	var offered []string
+	for _, tool := range s.toolsForTurn(t) {
+		offered = append(offered, tool.GetFunction().Name)
+	}
+	if t.system, err = s.prompts.Render(req.Prompt, req.Variables, offered); err != nil {
+		return nil, err
+	}
+	result, err = s.continueTurn(ctx, t)
+	return result, t.withSteps(err)
+}
+
+// continueTurn runs the agent loop from the turn's current state
+func (s *AgentService) continueTurn(ctx context.Context, t *turn) (*Result, error) {
+	for t.iteration < s.maxIterations {
+		t.iteration++
+
+		if err := s.budget.CheckDaily(s.ledger.Spent(t.caller), s.ledger.Now()); err != nil {
+			return nil, budgetExceeded(ctx, err)
 		}
 
-		finalResponse, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-			Model:    openai.ChatModelGPT4o,
-			Messages: messages,
-		})
+		offered := s.toolsForTurn(t)
+		started := time.Now()
+		response, answered, spent, err := s.complete(ctx, openai.ChatCompletionNewParams{
+			Model:    t.model,
+			Messages: s.prompt(t),
+			Tools:    offered,
+		}, t.req.Hooks)
+		// Stay on a fallback for the rest of the turn rather than retrying a failing model
+		t.model = answered
+		step := t.addStep(StepCompletion, started, spent, err)
+		for _, tool := range offered {
+			step.Tools = append(step.Tools, tool.GetFunction().Name)
+		}
 		if err != nil {
-			return "", err
+			slog.ErrorContext(ctx, "chat completion failed", "model", t.model, "iteration", t.iteration, "error", err)
+			return nil, err
+		}
+		t.total.Add(spent)
+		s.ledger.Add(t.caller, spent)
+		if err := s.budget.CheckRequest(t.total); err != nil {
+			return nil, budgetExceeded(ctx, err)
+		}
+		if len(response.Choices) == 0 {
+			return nil, fmt.Errorf("model %s returned no choices", t.model)
+		}
+
+		choice := response.Choices[0]
+		step.FinishReason = string(choice.FinishReason)
+		step.Content, _ = truncate(choice.Message.Content, stepResultLimit)
+		slog.DebugContext(ctx, "received completion", "iteration", t.iteration, "finish_reason", choice.FinishReason, "tool_calls", len(choice.Message.ToolCalls))
+		t.req.Hooks.completion(t.iteration, choice.FinishReason)
+
+		t.messages = append(t.messages, choice.Message.ToParam())
+
+		if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) == 0 {
+			attachClaims(choice.Message.Content, t.sources)
+			result := &Result{Response: choice.Message.Content, Route: t.route, Sources: t.sources}
+			if t.req.Schema != nil {
+				if result.Data, err = s.structure(ctx, t); err != nil {
+					return nil, err
+				}
+			}
+			// Set after structuring, which may fall back to another model
+			result.Model = t.model
+			result.Usage = t.total
+			metrics.Answers.WithLabelValues(metrics.ModelLabel(t.model), t.route).Inc()
+			if t.req.IncludeSteps {
+				result.Steps = t.steps
+			}
+
+			t.conv.Messages = t.messages
+			t.conv.Untrusted = t.untrusted
+			t.conv.SourceCount = t.sourceCount
+			slog.InfoContext(ctx, "query answered", logging.Content("response", choice.Message.Content),
+				"iterations", t.iteration, "total_tokens", t.total.TotalTokens)
+			return result, nil
+		}
+
+		for _, toolCall := range choice.Message.ToolCalls {
+			if s.requiresApproval(toolCall, t) {
+				t.toolCalls = choice.Message.ToolCalls
+				return s.pause(ctx, t), nil
+			}
 		}
+		s.runToolCalls(ctx, t, choice.Message.ToolCalls, nil)
+	}
+
+	return nil, fmt.Errorf("agent did not produce an answer within %d iterations", s.maxIterations)
+}
+
+// runToolCalls executes a batch of tool calls and appends their results. With a
+// decision, calls that require approval run only if it was approved. The whole batch was
+// chosen before any of its results were seen, so the turn only becomes untrusted after it.
+func (s *AgentService) runToolCalls(ctx context.Context, t *turn, toolCalls []openai.ChatCompletionMessageToolCallUnion, decision *Decision) {
+	untrusted := false
+	for _, toolCall := range toolCalls {
+		t.req.Hooks.toolCall(toolCall.Function.Name, toolCall.Function.Arguments)
+
+		started := time.Now()
+		var output, source string
+		var err error
+		if decision != nil && !decision.Approved && s.requiresApproval(toolCall, t) {
+			err = rejection(decision)
+			output = fmt.Sprintf("error: %v", err)
+		} else {
+			output, source, err = s.executeToolCall(ctx, toolCall, t)
+			if s.guard.UntrustedTool(toolCall.Function.Name) {
+				untrusted = true
+			}
+		}
+		t.req.Hooks.toolResult(toolCall.Function.Name, output)
+		t.addToolStep(toolCall.ID, toolCall.Function.Name, toolCall.Function.Arguments, source, output, started, err)
+
+		t.messages = append(t.messages, openai.ToolMessage(output, toolCall.ID))
+	}
+	if untrusted && !t.untrusted {
+		t.untrusted = true
+		slog.InfoContext(ctx, "untrusted tool output entered the conversation, restricting tools")
+	}
+}
 
-		return finalResponse.Choices[0].Message.Content, nil
+// prompt prepends the system prompt to the messages sent to the model. It is not
+// stored in the conversation, so every turn sees the current date.
+func (s *AgentService) prompt(t *turn) []openai.ChatCompletionMessageParamUnion {
+	system := t.system + "\n\n" + citationPrompt
+	if policy := s.guard.SystemPrompt(); policy != "" {
+		system += "\n\n" + policy
+	}
+	return append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(system)}, t.messages...)
+}
+
+// recordSource labels a tool call for citation and keeps its raw payload for auditing
+func (s *AgentService) recordSource(t *turn, function openai.ChatCompletionMessageFunctionToolCallFunction, exchange toolExchange, err error) Source {
+	t.sourceCount++
+	source := Source{
+		ID:        "src_" + newID(),
+		Label:     fmt.Sprintf("S%d", t.sourceCount),
+		Tool:      function.Name,
+		Service:   exchange.Service,
+		Arguments: function.Arguments,
+		Method:    exchange.Method,
+		URL:       exchange.URL,
+		Status:    exchange.Status,
+		Timestamp: time.Now().UTC(),
+		SHA256:    payloadDigest(exchange.Body),
+	}
+	if err != nil {
+		source.Error = err.Error()
 	}
+	s.sources.add(t.caller, source, exchange.Body)
+	t.sources = append(t.sources, source)
+	return source
+}
 
-	return choice.Message.Content, nil
+// Source returns a source recorded for the caller, with its raw payload
+func (s *AgentService) Source(id, caller string) (*SourcePayload, error) {
+	if caller == "" {
+		caller = AnonymousCaller
+	}
+	payload, ok := s.sources.get(id, caller)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSourceNotFound, id)
+	}
+	return payload, nil
 }
 
-func (s *AgentService) executeToolCall(ctx context.Context, toolCall openai.ChatCompletionMessageToolCallUnion) string {
+// Schema returns a named schema for structured answers
+func (s *AgentService) Schema(name string) (*schemas.Schema, error) {
+	return s.schemas.Get(name)
+}
+
+// Prompts lists the available system prompt templates
+func (s *AgentService) Prompts() []string {
+	return s.prompts.Names()
+}
+
+// permits checks that the turn may call a tool: it must be within the client's scopes
+// and, once untrusted output is in the conversation, still allowed by the guard
+func (s *AgentService) permits(t *turn, name string) error {
+	if !t.req.Access.Allows(name) {
+		return fmt.Errorf("%w: %s", ErrToolNotPermitted, name)
+	}
+	if t.untrusted && !s.guard.AllowsAfterUntrusted(name) {
+		return fmt.Errorf("%w: %s", ErrToolBlockedAfterUntrusted, name)
+	}
+	return nil
+}
+
+// toolsForTurn returns the tool definitions offered to the model for the turn
+func (s *AgentService) toolsForTurn(t *turn) []openai.ChatCompletionToolUnionParam {
+	var offered []openai.ChatCompletionToolUnionParam
+	for _, tool := range s.tools {
+		if fn := tool.GetFunction(); fn != nil && s.permits(t, fn.Name) == nil && (t.offered == nil || t.offered[fn.Name]) {
+			offered = append(offered, tool)
+		}
+	}
+	return offered
+}
+
+// selectTools narrows the tools offered for the turn to those ranked most relevant to the
+// query. The previous question is included so follow-ups keep their context.
+func (s *AgentService) selectTools(ctx context.Context, t *turn) {
+	var candidates []string
+	for _, tool := range s.tools {
+		if fn := tool.GetFunction(); fn != nil && s.permits(t, fn.Name) == nil {
+			candidates = append(candidates, fn.Name)
+		}
+	}
+
+	query := t.req.Query
+	for i := len(t.conv.Messages) - 1; i >= 0; i-- {
+		if user := t.conv.Messages[i].OfUser; user != nil {
+			query = user.Content.OfString.Value + "\n" + query
+			break
+		}
+	}
+
+	selected := s.selector.Select(ctx, query, candidates)
+	if selected == nil {
+		metrics.ToolsOffered.Observe(float64(len(candidates)))
+		return
+	}
+	metrics.ToolsOffered.Observe(float64(len(selected)))
+	t.offered = make(map[string]bool, len(selected))
+	for _, name := range selected {
+		t.offered[name] = true
+	}
+	slog.DebugContext(ctx, "selected tools", "tools", selected, "candidates", len(candidates))
+}
+
+func budgetExceeded(ctx context.Context, err error) error {
+	var budgetErr *usage.BudgetError
+	if errors.As(err, &budgetErr) {
+		metrics.BudgetExceeded.WithLabelValues(budgetErr.Scope).Inc()
+	}
+	slog.WarnContext(ctx, "query stopped by budget", "error", err)
+	return err
+}
+
+// completeWith requests a single completion from the provider serving params.Model and
+// records its latency, token usage and cost
+func (s *AgentService) completeWith(ctx context.Context, params openai.ChatCompletionNewParams, hooks *Hooks) (*openai.ChatCompletion, usage.Usage, error) {
+	model := metrics.ModelLabel(params.Model)
+	metrics.AgentIterations.WithLabelValues(model).Inc()
+
+	ctx, span := s.startChatSpan(ctx, params)
+	start := time.Now()
+	response, err := s.requestCompletion(ctx, s.providerFor(params.Model), params, hooks)
+	s.endChatSpan(span, response, err)
+	metrics.CompletionDuration.WithLabelValues(model, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, usage.Usage{}, err
+	}
+
+	spent := s.prices.Cost(params.Model, response.Usage.PromptTokens, response.Usage.CompletionTokens)
+	metrics.Tokens.WithLabelValues(model, "prompt").Add(float64(spent.PromptTokens))
+	metrics.Tokens.WithLabelValues(model, "completion").Add(float64(spent.CompletionTokens))
+	metrics.Cost.WithLabelValues(model).Add(spent.CostUSD)
+
+	return response, spent, nil
+}
+
+// requestCompletion calls the model, streaming content deltas to the hooks when requested
+func (s *AgentService) requestCompletion(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams, hooks *Hooks) (*openai.ChatCompletion, error) {
+	if hooks == nil || hooks.OnContent == nil {
+		return client.Chat.Completions.New(ctx, params)
+	}
+
+	// Usage is only sent as a final chunk when explicitly requested
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			hooks.OnContent(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	return &acc.ChatCompletion, nil
+}
+
+// Usage reports today's spend per caller
+func (s *AgentService) Usage() usage.Report {
+	return s.ledger.Report()
+}
+
+// Tools returns the tool definitions offered to the model
+func (s *AgentService) Tools() []openai.ChatCompletionToolUnionParam {
+	return s.tools
+}
+
+// ToolAccess resolves a client's scopes to the tools it may use
+func (s *AgentService) ToolAccess(scopes []string) *auth.ToolAccess {
+	return s.policy.Access(scopes)
+}
+
+// toolExchange is the backend request and response behind a tool call
+type toolExchange struct {
+	Service string
+	Method  string
+	URL     string
+	Status  int
+	Body    string
+}
+
+func (s *AgentService) callTool(ctx context.Context, name string, args map[string]interface{}) (toolExchange, error) {
+	metadata, ok := tools.GetToolMetadata(name)
+	if !ok {
+		metrics.ToolErrors.WithLabelValues(metrics.Unknown, metrics.Unknown).Inc()
+		return toolExchange{}, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	exchange := toolExchange{Service: metadata.Service}
+
+	slog.DebugContext(ctx, "resolved tool", "tool", name, "service", metadata.Service, "method", metadata.Method, "path", metadata.Path)
+	if inProcess(metadata) {
+		start := time.Now()
+		exchange.Method, exchange.URL = metadata.Method, inProcessURL(metadata.Service, name)
+		var err error
+		if metadata.Service == tools.ServiceWorkflow {
+			exchange.Body, err = s.runWorkflow(ctx, name, args)
+		} else {
+			exchange.Body, err = tools.RunLocal(name, args)
+		}
+		if err == nil {
+			exchange.Status = http.StatusOK
+		}
+		observeToolCall(name, metadata.Service, exchange.Status, err, time.Since(start))
+		return exchange, err
+	}
+	client, err := s.clientFor(metadata.Service)
+	if err != nil {
+		return exchange, err
+	}
+
+	start := time.Now()
+	req, err := client.BuildRequest(ctx, metadata, args)
+	if err != nil {
+		observeToolCall(name, metadata.Service, 0, err, time.Since(start))
+		return exchange, err
+	}
+	exchange.Method, exchange.URL = req.Method, req.URL.Redacted()
+
+	exchange.Status, exchange.Body, err = client.Send(req)
+	observeToolCall(name, metadata.Service, exchange.Status, err, time.Since(start))
+	return exchange, err
+}
+
+// inProcess reports whether a tool runs in the agent rather than as one backend request
+func inProcess(metadata tools.ToolMetadata) bool {
+	return metadata.Service == tools.ServiceLocal || metadata.Service == tools.ServiceWorkflow
+}
+
+// inProcessURL identifies a local tool or workflow in sources and invocations
+func inProcessURL(service, name string) string {
+	return service + ":" + name
+}
+
+func observeToolCall(name, service string, status int, err error, elapsed time.Duration) {
+	tool := metrics.ToolLabel(name, true)
+	metrics.ToolCallDuration.WithLabelValues(tool, service, metrics.StatusLabel(status, err)).Observe(elapsed.Seconds())
+
+	if err != nil || status < 200 || status > 299 {
+		metrics.ToolErrors.WithLabelValues(tool, service).Inc()
+	}
+	if source, _ := tools.LoadedSource(); source == tools.SourceFallback {
+		metrics.FallbackToolCalls.WithLabelValues(tool).Inc()
+	}
+}
+
+// executeToolCall runs a tool call and returns the output for the model, the label of
+// the source if a backend was called, and the error if the call did not succeed
+func (s *AgentService) executeToolCall(ctx context.Context, toolCall openai.ChatCompletionMessageToolCallUnion, t *turn) (string, string, error) {
 	switch toolCall.Type {
 	case "function":
-		functionToolCall := toolCall.AsFunction()
-		log.Printf("AgentService: executing function tool %s", functionToolCall.Function.Name)
+		// Read the union fields directly: AsFunction relies on raw JSON, which is
+		// not populated for completions assembled from a stream
+		function := toolCall.Function
+		ctx, span := s.startToolSpan(ctx, function.Name, toolCall.ID, function.Arguments)
+
+		// The model only sees permitted tools, but may still name others it has
+		// seen in history, invented, or was told to call by tool output
+		if err := s.permits(t, function.Name); err != nil {
+			s.endToolSpan(span, "", err)
+			_, known := tools.GetToolMetadata(function.Name)
+			metrics.ToolCallsDenied.WithLabelValues(metrics.ToolLabel(function.Name, known)).Inc()
+			slog.WarnContext(ctx, "tool call denied", "tool", function.Name, "error", err)
+			return fmt.Sprintf("error: %v", err), "", err
+		}
+
+		slog.InfoContext(ctx, "executing tool", "tool", function.Name, "tool_call_id", toolCall.ID, logging.Content("arguments", function.Arguments))
 
 		var args map[string]interface{}
-		json.Unmarshal([]byte(functionToolCall.Function.Arguments), &args)
-
-		if metadata, ok := tools.GetToolMetadata(functionToolCall.Function.Name); ok {
-			log.Printf("AgentService: resolved service %s for tool %s (method=%s path=%s)", metadata.Service, functionToolCall.Function.Name, metadata.Method, metadata.Path)
-			switch metadata.Service {
-			case tools.ServiceRotoReader:
-				data, err := s.rotoreader.ExecuteOperation(ctx, functionToolCall.Function.Name, args)
-				if err != nil {
-					log.Printf("AgentService: rotoreader error for %s: %v", functionToolCall.Function.Name, err)
-					return fmt.Sprintf("error: %v", err)
-				}
-				return data
-			case tools.ServiceOddsTracker:
-				data, err := s.oddstracker.ExecuteOperation(ctx, functionToolCall.Function.Name, args)
-				if err != nil {
-					log.Printf("AgentService: oddstracker error for %s: %v", functionToolCall.Function.Name, err)
-					return fmt.Sprintf("error: %v", err)
-				}
-				return data
-			default:
-				log.Printf("AgentService: unsupported service %s for tool %s", metadata.Service, functionToolCall.Function.Name)
-				return fmt.Sprintf("error: unsupported service %s", metadata.Service)
-			}
+		json.Unmarshal([]byte(function.Arguments), &args)
+		if changed := s.normalizeArguments(function.Name, args); len(changed) > 0 {
+			slog.InfoContext(ctx, "normalised tool arguments", "tool", function.Name, "arguments", changed)
 		}
 
-		log.Printf("AgentService: unknown function tool %s", functionToolCall.Function.Name)
-		return "unknown function"
+		exchange, err := s.callTool(ctx, function.Name, args)
+		s.endToolSpan(span, exchange.Body, err)
+		source := s.recordSource(t, function, exchange, err)
+		if err != nil {
+			slog.WarnContext(ctx, "tool failed", "tool", function.Name, "source", source.ID, "error", err)
+			return fmt.Sprintf("error: %v", err), source.Label, err
+		}
+		slog.DebugContext(ctx, "tool returned", "tool", function.Name, "source", source.ID, logging.Content("result", exchange.Body))
+		return s.guard.Output(ctx, source.Label, function.Name, exchange.Body), source.Label, nil
 	default:
-		log.Printf("AgentService: unsupported tool type %s", toolCall.Type)
-		return "unsupported tool type"
+		slog.WarnContext(ctx, "unsupported tool type", "type", toolCall.Type)
+		return "unsupported tool type", "", fmt.Errorf("unsupported tool type %q", toolCall.Type)
 	}
 }
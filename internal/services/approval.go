@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"sportsagent/internal/metrics"
+	"sportsagent/internal/tools"
+	"sportsagent/internal/usage"
+
+	"github.com/openai/openai-go/v3"
+)
+
+var (
+	// ErrApprovalNotFound is returned for unknown, expired or already decided approvals,
+	// and for approvals that belong to a different caller
+	ErrApprovalNotFound = errors.New("approval not found")
+	// ErrApprovalRequired is returned by ProcessQuery, which cannot pause for a decision
+	ErrApprovalRequired = errors.New("tool call requires approval")
+)
+
+// PendingApproval describes the tool calls a paused query is waiting to make
+type PendingApproval struct {
+	ID        string             `json:"id"`
+	ToolCalls []ProposedToolCall `json:"toolCalls"`
+	ExpiresAt time.Time          `json:"expiresAt"`
+}
+
+// ProposedToolCall is one call the model asked for. Calls that do not require approval
+// run together with the approved ones once a decision is made.
+type ProposedToolCall struct {
+	ID               string `json:"id"`
+	Tool             string `json:"tool"`
+	Service          string `json:"service,omitempty"`
+	Method           string `json:"method,omitempty"`
+	Path             string `json:"path,omitempty"`
+	Arguments        string `json:"arguments"`
+	RequiresApproval bool   `json:"requiresApproval"`
+}
+
+// Decision is a client's answer to a pending approval
+type Decision struct {
+	Approved bool
+	// Reason is passed to the model when the calls are rejected
+	Reason string
+}
+
+// turn is the state of a query in progress, kept while it waits for approval
+type turn struct {
+	conv      *Conversation
+	req       Request
+	model     string
+	caller    string
+	system    string
+	messages  []openai.ChatCompletionMessageParamUnion
+	total     usage.Usage
+	iteration int
+	// toolCalls are the calls awaiting a decision
+	toolCalls []openai.ChatCompletionMessageToolCallUnion
+	expiresAt time.Time
+	// untrusted is set once output from an untrusted service is in the messages
+	untrusted bool
+	// sources are the tool calls made so far; sourceCount numbers their labels across
+	// the whole conversation
+	sources     []Source
+	sourceCount int
+	steps       []Step
+	route       string
+	// offered limits the tools sent to the model to those selected for the query; nil
+	// sends every permitted tool
+	offered map[string]bool
+}
+
+// approvalStore keeps paused turns in memory until they are decided or expire
+type approvalStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*turn
+}
+
+func newApprovalStore(ttl time.Duration) *approvalStore {
+	return &approvalStore{ttl: ttl, now: time.Now, pending: map[string]*turn{}}
+}
+
+// add parks t and returns its ID
+func (a *approvalStore) add(t *turn) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	for id, p := range a.pending {
+		if now.After(p.expiresAt) {
+			delete(a.pending, id)
+			metrics.Approvals.WithLabelValues("expired").Inc()
+		}
+	}
+
+	id := newID()
+	t.expiresAt = now.Add(a.ttl)
+	a.pending[id] = t
+	return id
+}
+
+// newID returns a random identifier for pending approvals and sources
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// take removes and returns the turn so a decision is applied at most once
+func (a *approvalStore) take(id, caller string) (*turn, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t, ok := a.pending[id]
+	if !ok || t.caller != caller {
+		return nil, false
+	}
+	delete(a.pending, id)
+	if a.now().After(t.expiresAt) {
+		metrics.Approvals.WithLabelValues("expired").Inc()
+		return nil, false
+	}
+	return t, true
+}
+
+// requiresApproval reports whether a tool call has side effects that need a human
+// decision. Calls the turn may not make are rejected later without asking.
+func (s *AgentService) requiresApproval(toolCall openai.ChatCompletionMessageToolCallUnion, t *turn) bool {
+	if !s.approvalRequired || toolCall.Type != "function" || s.permits(t, toolCall.Function.Name) != nil {
+		return false
+	}
+	metadata, ok := tools.GetToolMetadata(toolCall.Function.Name)
+	return ok && !tools.ReadOnly(metadata)
+}
+
+// RequiresApproval reports whether the agent would pause for a decision before calling
+// the tool, so direct invocation can hold it to the same rule
+func (s *AgentService) RequiresApproval(name string) bool {
+	metadata, ok := tools.GetToolMetadata(name)
+	return s.approvalRequired && ok && !tools.ReadOnly(metadata)
+}
+
+// pause parks the turn and describes the calls waiting for a decision
+func (s *AgentService) pause(ctx context.Context, t *turn) *Result {
+	id := s.approvals.add(t)
+
+	pending := &PendingApproval{ID: id, ExpiresAt: t.expiresAt}
+	for _, toolCall := range t.toolCalls {
+		proposed := ProposedToolCall{
+			ID:               toolCall.ID,
+			Tool:             toolCall.Function.Name,
+			Arguments:        toolCall.Function.Arguments,
+			RequiresApproval: s.requiresApproval(toolCall, t),
+		}
+		if metadata, ok := tools.GetToolMetadata(toolCall.Function.Name); ok {
+			proposed.Service, proposed.Method, proposed.Path = metadata.Service, metadata.Method, metadata.Path
+		}
+		if proposed.RequiresApproval {
+			metrics.Approvals.WithLabelValues("requested").Inc()
+		}
+		pending.ToolCalls = append(pending.ToolCalls, proposed)
+	}
+
+	slog.InfoContext(ctx, "query paused for approval", "approval_id", id, "tool_calls", len(t.toolCalls))
+	result := &Result{Model: t.model, Route: t.route, Usage: t.total, Pending: pending, Sources: t.sources}
+	if t.req.IncludeSteps {
+		result.Steps = t.steps
+	}
+	return result
+}
+
+// Resume applies a decision to a paused query and continues it. Rejected calls are
+// answered with an error the model can explain to the user.
+func (s *AgentService) Resume(ctx context.Context, id, caller string, decision Decision, hooks *Hooks) (result *Result, err error) {
+	if caller == "" {
+		caller = AnonymousCaller
+	}
+	t, ok := s.approvals.take(id, caller)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.QueryDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	}()
+	ctx, span := startAgentSpan(ctx, t.model)
+	defer func() { endSpan(span, err) }()
+
+	outcome := "rejected"
+	if decision.Approved {
+		outcome = "approved"
+	}
+	metrics.Approvals.WithLabelValues(outcome).Inc()
+	slog.InfoContext(ctx, "approval decided", "approval_id", id, "outcome", outcome)
+
+	t.req.Hooks = hooks
+	toolCalls := t.toolCalls
+	t.toolCalls = nil
+	s.runToolCalls(ctx, t, toolCalls, &decision)
+
+	result, err = s.continueTurn(ctx, t)
+	return result, t.withSteps(err)
+}
+
+// rejection is the tool error for a call the client declined
+func rejection(decision *Decision) error {
+	if decision.Reason == "" {
+		return errors.New("the user rejected this operation")
+	}
+	return errors.New("the user rejected this operation: " + decision.Reason)
+}
@@ -0,0 +1,52 @@
+package services
+
+import "github.com/openai/openai-go/v3"
+
+// Conversation holds the message history of a multi-turn chat session
+type Conversation struct {
+	Messages []openai.ChatCompletionMessageParamUnion
+	// Untrusted is set once output from an untrusted service is in the history, which
+	// restricts the tools available for the rest of the conversation
+	Untrusted bool
+	// SourceCount is the number of tool calls recorded, so source labels stay unique
+	// across turns
+	SourceCount int
+}
+
+func NewConversation() *Conversation {
+	return &Conversation{}
+}
+
+// Reset clears the conversation history
+func (c *Conversation) Reset() {
+	c.Messages = nil
+	c.Untrusted = false
+	c.SourceCount = 0
+}
+
+// Hooks receives progress events while the agent works through a query.
+// Any callback may be nil; setting OnContent switches completions to streaming.
+type Hooks struct {
+	OnCompletion func(iteration int, finishReason string)
+	OnToolCall   func(name, arguments string)
+	OnToolResult func(name, result string)
+	OnContent    func(delta string)
+}
+
+func (h *Hooks) completion(iteration int, finishReason string) {
+	if h != nil && h.OnCompletion != nil {
+		h.OnCompletion(iteration, finishReason)
+	}
+}
+
+func (h *Hooks) toolCall(name, arguments string) {
+	if h != nil && h.OnToolCall != nil {
+		h.OnToolCall(name, arguments)
+	}
+}
+
+func (h *Hooks) toolResult(name, result string) {
+	if h != nil && h.OnToolResult != nil {
+		h.OnToolResult(name, result)
+	}
+}
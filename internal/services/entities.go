@@ -0,0 +1,60 @@
+package services
+
+import (
+	"path"
+	"sort"
+
+	"sportsagent/internal/tools"
+)
+
+// normalizeArguments rewrites string arguments that name a player or team to the spelling
+// of the service the tool calls, so "Pat Mahomes" reaches a backend that lists "Patrick
+// Mahomes" as such. Unknown and ambiguous names are left alone. It returns the names of
+// the arguments it changed.
+func (s *AgentService) normalizeArguments(tool string, args map[string]interface{}) []string {
+	if len(s.normalize) == 0 || s.entities == nil {
+		return nil
+	}
+	metadata, ok := tools.GetToolMetadata(tool)
+	if !ok || metadata.Service == tools.ServiceLocal {
+		return nil
+	}
+
+	var changed []string
+	for name, value := range args {
+		if !s.normalizes(name) {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if spelled, ok := s.entities.Normalize(metadata.Service, v); ok && spelled != v {
+				args[name] = spelled
+				changed = append(changed, name)
+			}
+		case []interface{}:
+			rewritten := false
+			for i, item := range v {
+				if text, isString := item.(string); isString {
+					if spelled, ok := s.entities.Normalize(metadata.Service, text); ok && spelled != text {
+						v[i] = spelled
+						rewritten = true
+					}
+				}
+			}
+			if rewritten {
+				changed = append(changed, name)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (s *AgentService) normalizes(name string) bool {
+	for _, pattern := range s.normalize {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
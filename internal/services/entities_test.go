@@ -0,0 +1,56 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sportsagent/internal/entities"
+	"sportsagent/internal/tools"
+)
+
+func TestNormalizeArguments(t *testing.T) {
+	// Unreachable specs register the fallback tools
+	tools.GetTools(tools.SpecSources("http://127.0.0.1:1", "http://127.0.0.1:1"))
+
+	path := filepath.Join(t.TempDir(), "entities.yaml")
+	os.WriteFile(path, []byte("spelling:\n  oddstracker: abbreviation\n"), 0o644)
+	resolver, err := entities.Load(path, entities.DefaultMinScore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := &AgentService{entities: resolver, normalize: []string{"team", "*_team"}}
+
+	args := map[string]interface{}{
+		"team":      "Kansas City Chiefs",
+		"home_team": "Philly",
+		"teams":     "Chiefs",
+		"away_team": []interface{}{"Niners", "New York"},
+		"player":    "Mahomes",
+	}
+	changed := agent.normalizeArguments("get_odds_data", args)
+
+	if want := []string{"away_team", "home_team", "team"}; !reflect.DeepEqual(changed, want) {
+		t.Fatalf("changed = %v, want %v", changed, want)
+	}
+	want := map[string]interface{}{
+		"team":      "KC",
+		"home_team": "PHI",
+		"teams":     "Chiefs",
+		"away_team": []interface{}{"SF", "New York"},
+		"player":    "Mahomes",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+
+	// Local tools take names as written, and nothing changes unless enabled
+	if changed := agent.normalizeArguments("resolve_entity", map[string]interface{}{"team": "KC"}); changed != nil {
+		t.Fatalf("local tool arguments should not change, got %v", changed)
+	}
+	agent.normalize = nil
+	if changed := agent.normalizeArguments("get_odds_data", map[string]interface{}{"team": "Chiefs"}); changed != nil {
+		t.Fatalf("normalisation should be off, got %v", changed)
+	}
+}
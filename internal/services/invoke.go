@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"sportsagent/internal/tools"
+)
+
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ErrToolNotPermitted is returned for tools outside the caller's scopes
+var ErrToolNotPermitted = errors.New("tool not permitted for this client")
+
+// ErrToolBlockedAfterUntrusted is returned for tools withheld once untrusted tool output
+// is in the conversation
+var ErrToolBlockedAfterUntrusted = errors.New("tool not permitted after untrusted tool output")
+
+// serviceClient is implemented by the backend clients that execute tool operations
+type serviceClient interface {
+	BuildRequest(ctx context.Context, metadata tools.ToolMetadata, params map[string]interface{}) (*http.Request, error)
+	Send(req *http.Request) (int, string, error)
+}
+
+// ToolInvocation describes a tool call made directly, bypassing the model
+type ToolInvocation struct {
+	Tool     string              `json:"tool"`
+	Service  string              `json:"service"`
+	Request  InvocationRequest   `json:"request"`
+	Response *InvocationResponse `json:"response,omitempty"`
+}
+
+type InvocationRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type InvocationResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body"`
+}
+
+func (s *AgentService) clientFor(service string) (serviceClient, error) {
+	switch service {
+	case tools.ServiceRotoReader:
+		return s.rotoreader, nil
+	case tools.ServiceOddsTracker:
+		return s.oddstracker, nil
+	default:
+		return nil, fmt.Errorf("unsupported service %s", service)
+	}
+}
+
+// InspectToolCall validates the arguments for a tool, builds the backend request and,
+// unless dryRun is set, sends it. Validation and request construction failures wrap
+// tools.ErrInvalidArguments so callers can tell them apart from backend failures.
+func (s *AgentService) InspectToolCall(ctx context.Context, name string, args map[string]interface{}, dryRun bool) (*ToolInvocation, error) {
+	fn, ok := tools.FindTool(s.tools, name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	metadata, ok := tools.GetToolMetadata(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+
+	if err := tools.ValidateArguments(fn.Parameters, args); err != nil {
+		return nil, err
+	}
+	if inProcess(metadata) {
+		return s.inspectInProcessCall(ctx, name, metadata, args, dryRun)
+	}
+
+	client, err := s.clientFor(metadata.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := client.BuildRequest(ctx, metadata, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", tools.ErrInvalidArguments, err)
+	}
+
+	invocation := &ToolInvocation{
+		Tool:    name,
+		Service: metadata.Service,
+		Request: InvocationRequest{Method: req.Method, URL: req.URL.Redacted()},
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		invocation.Request.Body = string(data)
+	}
+
+	if dryRun {
+		return invocation, nil
+	}
+
+	slog.InfoContext(ctx, "direct tool invocation", "tool", name, "method", invocation.Request.Method, "path", req.URL.Path)
+	status, body, err := client.Send(req)
+	if err != nil {
+		return nil, err
+	}
+	invocation.Response = &InvocationResponse{StatusCode: status, Body: body}
+
+	return invocation, nil
+}
+
+// inspectInProcessCall describes and, unless dryRun is set, runs a local tool or workflow
+func (s *AgentService) inspectInProcessCall(ctx context.Context, name string, metadata tools.ToolMetadata, args map[string]interface{}, dryRun bool) (*ToolInvocation, error) {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", tools.ErrInvalidArguments, err)
+	}
+	invocation := &ToolInvocation{
+		Tool:    name,
+		Service: metadata.Service,
+		Request: InvocationRequest{Method: metadata.Method, URL: inProcessURL(metadata.Service, name), Body: string(body)},
+	}
+	if dryRun {
+		return invocation, nil
+	}
+
+	slog.InfoContext(ctx, "direct tool invocation", "tool", name, "method", metadata.Method)
+	exchange, err := s.callTool(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	invocation.Response = &InvocationResponse{StatusCode: exchange.Status, Body: exchange.Body}
+	return invocation, nil
+}
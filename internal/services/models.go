@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/metrics"
+	"sportsagent/internal/usage"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// Routes describe how the model answering a query was chosen
+const (
+	RouteDefault   = "default"
+	RouteRequested = "requested"
+	RouteSimple    = "simple"
+	RouteComplex   = "complex"
+)
+
+// Routing modes
+const (
+	routingOff        = "off"
+	routingHeuristic  = "heuristic"
+	routingClassifier = "classifier"
+)
+
+// complexQueryWords is the query length above which the heuristic router treats a query
+// as needing analysis rather than a single lookup
+const complexQueryWords = 25
+
+// complexCues are phrases that suggest comparing or reasoning over several results
+var complexCues = []string{
+	"analy", "compare", "comparison", "versus", " vs ", " vs.", "trend", "histor",
+	"predict", "project", "why", "impact", "correlat", "across", "should i", "best bet", "value",
+	"explain", "breakdown", "break down",
+}
+
+const classifierPrompt = `Classify the user's sports question for routing. Answer "simple" if it can be answered
+with a single lookup of news or odds, or "complex" if it needs several lookups, comparison or
+analysis. Reply with exactly one word: simple or complex.`
+
+// newClient builds an OpenAI-compatible client; empty settings use the SDK defaults
+func newClient(baseURL, apiKey string) *openai.Client {
+	opts := []option.RequestOption{}
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	client := openai.NewClient(opts...)
+	return &client
+}
+
+// modelClients returns clients for fallbacks served by a different provider. Settings
+// a fallback leaves empty are taken from the primary provider.
+func modelClients(cfg config.OpenAIConfig) map[string]*openai.Client {
+	clients := map[string]*openai.Client{}
+	for _, fallback := range cfg.Fallbacks {
+		if fallback.BaseURL == "" && fallback.APIKey == "" {
+			continue
+		}
+		baseURL, apiKey := fallback.BaseURL, fallback.APIKey
+		if baseURL == "" {
+			baseURL = cfg.BaseURL
+		}
+		if apiKey == "" {
+			apiKey = cfg.APIKey
+		}
+		clients[fallback.Model] = newClient(baseURL, apiKey)
+	}
+	return clients
+}
+
+// providerFor returns the client for the provider serving model
+func (s *AgentService) providerFor(model string) *openai.Client {
+	if client, ok := s.clients[model]; ok {
+		return client
+	}
+	return s.client
+}
+
+// modelChain is model followed by the configured fallbacks, without repeats
+func (s *AgentService) modelChain(model string) []string {
+	chain := []string{model}
+	for _, fallback := range s.fallbacks {
+		if !slices.Contains(chain, fallback) {
+			chain = append(chain, fallback)
+		}
+	}
+	return chain
+}
+
+// retryable reports whether a failed completion may succeed on another model: rate
+// limits, timeouts, server errors and failures to reach the provider. The SDK has
+// already retried the same model by the time this is consulted.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusConflict,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= http.StatusInternalServerError:
+			return true
+		}
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// route picks the model for a turn whose request did not name one
+func (s *AgentService) route(ctx context.Context, t *turn) {
+	if s.routing.Mode == routingOff {
+		t.route = RouteDefault
+		return
+	}
+
+	complex := complexQuery(t.req.Query)
+	if s.routing.Mode == routingClassifier {
+		var err error
+		if complex, err = s.classify(ctx, t); err != nil {
+			slog.WarnContext(ctx, "query classifier failed, using the heuristic", "error", err)
+			complex = complexQuery(t.req.Query)
+		}
+	}
+
+	t.route, t.model = RouteSimple, s.routing.SimpleModel
+	if complex {
+		t.route, t.model = RouteComplex, s.routing.ComplexModel
+	}
+	if t.model == "" {
+		t.model = s.model
+	}
+	slog.InfoContext(ctx, "routed query", "route", t.route, "model", t.model)
+}
+
+// complexQuery guesses from the wording whether a query needs several tools or analysis
+func complexQuery(query string) bool {
+	lower := " " + strings.ToLower(query) + " "
+	if len(strings.Fields(lower)) > complexQueryWords {
+		return true
+	}
+	for _, cue := range complexCues {
+		if strings.Contains(lower, cue) {
+			return true
+		}
+	}
+	return false
+}
+
+// classify asks the classifier model whether the query is complex. Its usage is charged
+// to the turn.
+func (s *AgentService) classify(ctx context.Context, t *turn) (bool, error) {
+	model := s.routing.ClassifierModel
+	if model == "" {
+		model = s.routing.SimpleModel
+	}
+	if model == "" {
+		model = s.model
+	}
+
+	started := time.Now()
+	response, answered, spent, err := s.complete(ctx, openai.ChatCompletionNewParams{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(classifierPrompt),
+			openai.UserMessage(t.req.Query),
+		},
+		MaxCompletionTokens: openai.Int(5),
+	}, nil)
+	step := t.addStep(StepRoute, started, spent, err)
+	step.Model = answered
+	if err != nil {
+		return false, err
+	}
+	t.total.Add(spent)
+	s.ledger.Add(t.caller, spent)
+	if len(response.Choices) == 0 {
+		return false, errors.New("classifier returned no choices")
+	}
+	label := strings.ToLower(strings.TrimSpace(response.Choices[0].Message.Content))
+	step.FinishReason = string(response.Choices[0].FinishReason)
+	step.Content = label
+	switch {
+	case strings.HasPrefix(label, "complex"):
+		return true, nil
+	case strings.HasPrefix(label, "simple"):
+		return false, nil
+	}
+	return false, errors.New("classifier answered " + label)
+}
+
+// complete requests a completion from params.Model and, on retryable errors, from each
+// fallback in turn. It returns the model that answered. Streams are not retried once
+// content has reached the hooks.
+func (s *AgentService) complete(ctx context.Context, params openai.ChatCompletionNewParams, hooks *Hooks) (*openai.ChatCompletion, string, usage.Usage, error) {
+	streamed := false
+	if hooks != nil && hooks.OnContent != nil {
+		wrapped, onContent := *hooks, hooks.OnContent
+		wrapped.OnContent = func(delta string) {
+			streamed = true
+			onContent(delta)
+		}
+		hooks = &wrapped
+	}
+
+	chain := s.modelChain(params.Model)
+	var err error
+	for i, model := range chain {
+		params.Model = model
+		var response *openai.ChatCompletion
+		var spent usage.Usage
+		if response, spent, err = s.completeWith(ctx, params, hooks); err == nil {
+			return response, model, spent, nil
+		}
+		if i == len(chain)-1 || streamed || !retryable(ctx, err) {
+			break
+		}
+		metrics.ModelFallbacks.WithLabelValues(metrics.ModelLabel(model), metrics.ModelLabel(chain[i+1])).Inc()
+		slog.WarnContext(ctx, "completion failed, falling back", "model", model, "fallback", chain[i+1], "error", err)
+	}
+	return nil, params.Model, usage.Usage{}, err
+}
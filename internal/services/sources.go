@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sportsagent/internal/config"
+)
+
+// ErrSourceNotFound is returned for unknown or expired sources, and for sources recorded
+// for a different caller
+var ErrSourceNotFound = errors.New("source not found")
+
+// citationPrompt asks the model to reference the tool calls behind its answer
+const citationPrompt = `Every tool result is labelled with a source such as S1. After each statement that relies on a tool result, cite its label in square brackets, for example "The Chiefs are 3.5-point favourites [S1]." Cite only labels you were given.`
+
+// Source records a tool call made while answering a query
+type Source struct {
+	// ID retrieves the raw payload from GET /sources/{id}
+	ID string `json:"id"`
+	// Label is how the model cites the source in the answer, e.g. S1
+	Label     string    `json:"label"`
+	Tool      string    `json:"tool"`
+	Service   string    `json:"service,omitempty"`
+	Arguments string    `json:"arguments"`
+	Method    string    `json:"method,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// SHA256 is the hex digest of the raw payload
+	SHA256 string `json:"sha256"`
+	// Claims are the sentences of the answer that cite this source
+	Claims []string `json:"claims,omitempty"`
+}
+
+// SourcePayload is a recorded source with the raw backend response
+type SourcePayload struct {
+	Source  Source `json:"source"`
+	Payload string `json:"payload"`
+}
+
+type storedSource struct {
+	SourcePayload
+	caller    string
+	expiresAt time.Time
+}
+
+// sourceStore keeps raw payloads in memory for auditing, oldest first
+type sourceStore struct {
+	retention  time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*storedSource
+	order   []string
+}
+
+func newSourceStore(cfg config.SourcesConfig) *sourceStore {
+	return &sourceStore{
+		retention:  cfg.Retention,
+		maxEntries: cfg.MaxEntries,
+		now:        time.Now,
+		entries:    map[string]*storedSource{},
+	}
+}
+
+// add stores a payload, dropping expired entries and the oldest ones beyond the limit
+func (s *sourceStore) add(caller string, source Source, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for len(s.order) > 0 {
+		oldest := s.entries[s.order[0]]
+		if len(s.order) < s.maxEntries && now.Before(oldest.expiresAt) {
+			break
+		}
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	s.entries[source.ID] = &storedSource{
+		SourcePayload: SourcePayload{Source: source, Payload: payload},
+		caller:        caller,
+		expiresAt:     now.Add(s.retention),
+	}
+	s.order = append(s.order, source.ID)
+}
+
+func (s *sourceStore) get(id, caller string) (*SourcePayload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || entry.caller != caller || !s.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	payload := entry.SourcePayload
+	return &payload, true
+}
+
+func payloadDigest(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// citationRe matches citations such as [S1] or [S1, S3]
+var (
+	citationRe = regexp.MustCompile(`\[(S\d+(?:\s*,\s*S\d+)*)\]`)
+	// citationSpaceRe also matches the space before a citation, for removing it
+	citationSpaceRe = regexp.MustCompile(`\s*` + citationRe.String())
+)
+
+// attachClaims maps each sentence of the answer to the sources it cites. A citation at
+// the start of a sentence belongs to the one before it, as in "-3.5. [S1]".
+func attachClaims(answer string, sources []Source) {
+	var previous string
+	for _, sentence := range splitSentences(answer) {
+		claim := strings.TrimSpace(citationSpaceRe.ReplaceAllString(sentence, ""))
+		if claim == "" {
+			claim = previous
+		}
+		for _, match := range citationRe.FindAllStringSubmatch(sentence, -1) {
+			for _, label := range strings.Split(match[1], ",") {
+				label = strings.TrimSpace(label)
+				for i := range sources {
+					if sources[i].Label == label && claim != "" && !slices.Contains(sources[i].Claims, claim) {
+						sources[i].Claims = append(sources[i].Claims, claim)
+					}
+				}
+			}
+		}
+		if claim != "" {
+			previous = claim
+		}
+	}
+}
+
+// splitSentences breaks text after sentence punctuation followed by whitespace, and at
+// line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		end := r == '\n' ||
+			(r == '.' || r == '!' || r == '?') && i+1 < len(runes) && (runes[i+1] == ' ' || runes[i+1] == '\n')
+		if end {
+			if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"sportsagent/internal/config"
+)
+
+func TestAttachClaims(t *testing.T) {
+	sources := []Source{{Label: "S1"}, {Label: "S2"}, {Label: "S3"}}
+	answer := "The Chiefs are 3.5-point favourites [S1]. Mahomes is questionable.\n" +
+		"The total moved to 47.5. [S1, S2]\nNo news on Kelce."
+
+	attachClaims(answer, sources)
+
+	if want := []string{"The Chiefs are 3.5-point favourites.", "The total moved to 47.5."}; !slices.Equal(sources[0].Claims, want) {
+		t.Fatalf("S1 claims = %q, want %q", sources[0].Claims, want)
+	}
+	if want := []string{"The total moved to 47.5."}; !slices.Equal(sources[1].Claims, want) {
+		t.Fatalf("S2 claims = %q, want %q", sources[1].Claims, want)
+	}
+	if len(sources[2].Claims) != 0 {
+		t.Fatalf("S3 is never cited, got %q", sources[2].Claims)
+	}
+}
+
+func TestSourceStore(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	store := newSourceStore(config.SourcesConfig{Retention: time.Hour, MaxEntries: 2})
+	store.now = func() time.Time { return now }
+
+	store.add("key-a", Source{ID: "src_1"}, "one")
+	store.add("key-a", Source{ID: "src_2"}, "two")
+
+	if payload, ok := store.get("src_1", "key-a"); !ok || payload.Payload != "one" {
+		t.Fatalf("expected src_1 for its caller, got %+v", payload)
+	}
+	if _, ok := store.get("src_1", "key-b"); ok {
+		t.Fatal("another caller must not read the payload")
+	}
+
+	store.add("key-a", Source{ID: "src_3"}, "three")
+	if _, ok := store.get("src_1", "key-a"); ok {
+		t.Fatal("the oldest entry should be evicted beyond maxEntries")
+	}
+
+	store.now = func() time.Time { return now.Add(time.Hour) }
+	if _, ok := store.get("src_3", "key-a"); ok {
+		t.Fatal("entries should expire after the retention period")
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"sportsagent/internal/usage"
+)
+
+// stepResultLimit bounds the tool results and model content copied into steps
+const stepResultLimit = 2000
+
+// Step kinds
+const (
+	StepCompletion = "completion"
+	StepStructure  = "structure"
+	// StepRoute is the classifier call choosing the model
+	StepRoute = "route"
+)
+
+// Step describes one model round trip and the tool calls it asked for. Steps are returned
+// when a request sets IncludeSteps, to diagnose answers without the tracing backend.
+type Step struct {
+	Kind         string      `json:"kind"`
+	Iteration    int         `json:"iteration"`
+	Model        string      `json:"model"`
+	FinishReason string      `json:"finishReason,omitempty"`
+	Content      string      `json:"content,omitempty"`
+	DurationMs   int64       `json:"durationMs"`
+	Usage        usage.Usage `json:"usage"`
+	Error        string      `json:"error,omitempty"`
+	// Tools are the tools offered to the model
+	Tools     []string   `json:"tools,omitempty"`
+	ToolCalls []StepTool `json:"toolCalls,omitempty"`
+}
+
+// StepTool is a tool call made in a step
+type StepTool struct {
+	ID        string `json:"id"`
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+	// Source is the citation label of the call, if it reached a backend
+	Source string `json:"source,omitempty"`
+	// Result is the output shown to the model, truncated to ResultBytes
+	Result      string `json:"result"`
+	ResultBytes int    `json:"resultBytes"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	DurationMs  int64  `json:"durationMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+// TurnError is returned instead of a bare error when the request asked for steps, so
+// a failed query can still be diagnosed
+type TurnError struct {
+	Err   error
+	Steps []Step
+}
+
+func (e *TurnError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TurnError) Unwrap() error {
+	return e.Err
+}
+
+// withSteps wraps err with the turn's steps if the request asked for them
+func (t *turn) withSteps(err error) error {
+	if err == nil || !t.req.IncludeSteps {
+		return err
+	}
+	return &TurnError{Err: err, Steps: t.steps}
+}
+
+// addStep records a completion and returns it so the response can be filled in
+func (t *turn) addStep(kind string, started time.Time, spent usage.Usage, err error) *Step {
+	step := Step{
+		Kind:       kind,
+		Iteration:  t.iteration,
+		Model:      t.model,
+		DurationMs: time.Since(started).Milliseconds(),
+		Usage:      spent,
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	t.steps = append(t.steps, step)
+	return &t.steps[len(t.steps)-1]
+}
+
+// addToolStep records a tool call against the latest step
+func (t *turn) addToolStep(id, tool, arguments, source, output string, started time.Time, err error) {
+	if len(t.steps) == 0 {
+		return
+	}
+	call := StepTool{
+		ID:          id,
+		Tool:        tool,
+		Arguments:   arguments,
+		Source:      source,
+		ResultBytes: len(output),
+		DurationMs:  time.Since(started).Milliseconds(),
+	}
+	call.Result, call.Truncated = truncate(output, stepResultLimit)
+	if err != nil {
+		call.Error = err.Error()
+	}
+	step := &t.steps[len(t.steps)-1]
+	step.ToolCalls = append(step.ToolCalls, call)
+}
+
+// truncate shortens s to at most limit bytes without splitting a character
+func truncate(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…", s[:cut]), true
+}
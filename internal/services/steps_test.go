@@ -0,0 +1,13 @@
+package services
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	if got, truncated := truncate("short", 10); got != "short" || truncated {
+		t.Fatalf("truncate(short) = %q, %t", got, truncated)
+	}
+	// "é" is two bytes; cutting at 3 would split the second one
+	if got, truncated := truncate("éé", 3); got != "é…" || !truncated {
+		t.Fatalf("truncate(éé) = %q, %t", got, truncated)
+	}
+}
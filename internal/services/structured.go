@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sportsagent/internal/metrics"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// structureAttempts is the number of completions allowed to produce conforming JSON
+const structureAttempts = 2
+
+const structurePrompt = "Return your previous answer as JSON matching the response schema. Use only facts from this conversation."
+
+// structure asks the model to restate the turn's answer as JSON matching the requested
+// schema. Output that fails validation is sent back once with the validation error.
+func (s *AgentService) structure(ctx context.Context, t *turn) (json.RawMessage, error) {
+	schema := t.req.Schema
+	format := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:   schema.Name,
+		Strict: openai.Bool(schema.Strict()),
+		Schema: schema.Definition,
+	}
+	if description := schema.Description(); description != "" {
+		format.Description = openai.String(description)
+	}
+
+	messages := append(s.prompt(t), openai.UserMessage(structurePrompt))
+	var err error
+	for attempt := 1; attempt <= structureAttempts; attempt++ {
+		started := time.Now()
+		response, answered, spent, cerr := s.complete(ctx, openai.ChatCompletionNewParams{
+			Model:    t.model,
+			Messages: messages,
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: format},
+			},
+		}, nil)
+		t.model = answered
+		step := t.addStep(StepStructure, started, spent, cerr)
+		if cerr != nil {
+			return nil, cerr
+		}
+		t.total.Add(spent)
+		s.ledger.Add(t.caller, spent)
+		if berr := s.budget.CheckRequest(t.total); berr != nil {
+			return nil, budgetExceeded(ctx, berr)
+		}
+		if len(response.Choices) == 0 {
+			return nil, fmt.Errorf("model %s returned no choices", t.model)
+		}
+
+		output := response.Choices[0].Message.Content
+		step.FinishReason = string(response.Choices[0].FinishReason)
+		step.Content, _ = truncate(output, stepResultLimit)
+		var data json.RawMessage
+		if data, err = schema.Validate(output); err == nil {
+			metrics.StructuredOutputs.WithLabelValues("valid").Inc()
+			return data, nil
+		}
+
+		step.Error = err.Error()
+		slog.WarnContext(ctx, "structured answer does not match schema", "schema", schema.Name, "attempt", attempt, "error", err)
+		messages = append(messages,
+			openai.AssistantMessage(output),
+			openai.UserMessage(fmt.Sprintf("That JSON does not match the response schema: %v. Return corrected JSON only.", err)))
+	}
+
+	metrics.StructuredOutputs.WithLabelValues("invalid").Inc()
+	return nil, fmt.Errorf("structured answer for schema %s: %w", schema.Name, err)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openai/openai-go/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const agentName = "sportsagent"
+
+// Tool content attributes from the GenAI conventions that have no semconv constant yet
+const (
+	genAIToolCallArgumentsKey = attribute.Key("gen_ai.tool.call.arguments")
+	genAIToolCallResultKey    = attribute.Key("gen_ai.tool.call.result")
+)
+
+var tracer = otel.Tracer("sportsagent/internal/services")
+
+// startAgentSpan starts the invoke_agent span wrapping every model and tool call of a turn
+func startAgentSpan(ctx context.Context, model string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "invoke_agent "+agentName,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameInvokeAgent,
+			semconv.GenAIProviderNameOpenAI,
+			semconv.GenAIAgentName(agentName),
+			semconv.GenAIRequestModel(model),
+		),
+	)
+}
+
+// startChatSpan starts a chat span for a single completion request. Messages are only
+// recorded when content capture is enabled.
+func (s *AgentService) startChatSpan(ctx context.Context, params openai.ChatCompletionNewParams) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "chat "+params.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameChat,
+			semconv.GenAIProviderNameOpenAI,
+			semconv.GenAIRequestModel(params.Model),
+		),
+	)
+
+	if s.captureContent {
+		span.SetAttributes(semconv.GenAIInputMessagesKey.String(marshalContent(params.Messages)))
+	}
+	return ctx, span
+}
+
+// endChatSpan records the response details on a chat span and ends it
+func (s *AgentService) endChatSpan(span trace.Span, response *openai.ChatCompletion, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	finishReasons := make([]string, 0, len(response.Choices))
+	for _, choice := range response.Choices {
+		finishReasons = append(finishReasons, choice.FinishReason)
+	}
+
+	span.SetAttributes(
+		semconv.GenAIResponseID(response.ID),
+		semconv.GenAIResponseModel(response.Model),
+		semconv.GenAIResponseFinishReasons(finishReasons...),
+		semconv.GenAIUsageInputTokens(int(response.Usage.PromptTokens)),
+		semconv.GenAIUsageOutputTokens(int(response.Usage.CompletionTokens)),
+	)
+
+	if s.captureContent && len(response.Choices) > 0 {
+		span.SetAttributes(semconv.GenAIOutputMessagesKey.String(marshalContent(response.Choices[0].Message.ToParam())))
+	}
+}
+
+// startToolSpan starts an execute_tool span for a single tool call
+func (s *AgentService) startToolSpan(ctx context.Context, name, callID, arguments string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "execute_tool "+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameExecuteTool,
+			semconv.GenAIToolName(name),
+			semconv.GenAIToolCallID(callID),
+			semconv.GenAIToolType("function"),
+		),
+	)
+
+	if s.captureContent {
+		span.SetAttributes(genAIToolCallArgumentsKey.String(arguments))
+	}
+	return ctx, span
+}
+
+// endToolSpan records the tool outcome on an execute_tool span and ends it
+func (s *AgentService) endToolSpan(span trace.Span, result string, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if s.captureContent {
+		span.SetAttributes(genAIToolCallResultKey.String(result))
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func marshalContent(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"sportsagent/internal/tools"
+)
+
+// runWorkflow runs a composite tool, calling each step's tool as a model tool call would,
+// and returns the results of its output steps as JSON
+func (s *AgentService) runWorkflow(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	w, ok := tools.Workflow(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+
+	results, err := w.Run(ctx, args, func(ctx context.Context, tool string, args map[string]any) (string, error) {
+		if changed := s.normalizeArguments(tool, args); len(changed) > 0 {
+			slog.InfoContext(ctx, "normalised tool arguments", "tool", tool, "workflow", name, "arguments", changed)
+		}
+		exchange, err := s.callTool(ctx, tool, args)
+		if err != nil {
+			return "", err
+		}
+		// The body is left out: errors reach the model without the tool output guard
+		if exchange.Status < 200 || exchange.Status > 299 {
+			return "", fmt.Errorf("%s returned status %d", exchange.URL, exchange.Status)
+		}
+		return exchange.Body, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,196 @@
+// Package telemetry configures OpenTelemetry trace export from the service configuration.
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"google.golang.org/grpc/credentials"
+)
+
+const serviceName = "go-sportsagent"
+
+// Setup installs the global tracer provider and propagators. When no exporter is
+// configured tracing stays disabled and the returned shutdown function is a no-op.
+func Setup(ctx context.Context, cfg config.TelemetryConfig) (func(context.Context) error, error) {
+	// Propagate incoming trace context even when this process does not export spans
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+
+	exp, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		slog.Info("tracing disabled: no exporter configured (telemetry.exporter, telemetry.otlpEndpoint)")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+	)
+	otel.SetTracerProvider(tp)
+
+	slog.Info("tracing enabled", "exporter", exporterName(cfg), "sampler", cfg.Sampler)
+	return tp.Shutdown, nil
+}
+
+// exporterName resolves the effective exporter, defaulting to OTLP when an endpoint is set
+func exporterName(cfg config.TelemetryConfig) string {
+	switch {
+	case cfg.Exporter == "console":
+		return "stdout"
+	case cfg.Exporter != "":
+		return cfg.Exporter
+	case cfg.OTLPEndpoint != "":
+		return "otlp"
+	default:
+		return "none"
+	}
+}
+
+func newExporter(ctx context.Context, cfg config.TelemetryConfig) (sdktrace.SpanExporter, error) {
+	switch exporterName(cfg) {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	case "otlp":
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.OTLPProtocol == "grpc" {
+			return newGRPCExporter(ctx, cfg, tlsConfig)
+		}
+		return newHTTPExporter(ctx, cfg, tlsConfig)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", cfg.Exporter)
+	}
+}
+
+func newHTTPExporter(ctx context.Context, cfg config.TelemetryConfig, tlsConfig *tls.Config) (sdktrace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint)}
+	if len(cfg.OTLPHeaders) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.OTLPHeaders))
+	}
+	switch {
+	case cfg.OTLPInsecure:
+		opts = append(opts, otlptracehttp.WithInsecure())
+	case tlsConfig != nil:
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+func newGRPCExporter(ctx context.Context, cfg config.TelemetryConfig, tlsConfig *tls.Config) (sdktrace.SpanExporter, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(cfg.OTLPEndpoint)}
+	if len(cfg.OTLPHeaders) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.OTLPHeaders))
+	}
+	switch {
+	case cfg.OTLPInsecure:
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	case tlsConfig != nil:
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// newTLSConfig loads a custom CA and client certificate; nil means system defaults
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read telemetry CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("telemetry CA file %s contains no certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load telemetry client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func newSampler(cfg config.TelemetryConfig) sdktrace.Sampler {
+	switch cfg.Sampler {
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "always_on":
+		return sdktrace.AlwaysSample()
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(cfg.SamplerArg)
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerArg))
+	default:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+}
+
+// newResource describes this process. OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+// take precedence over the built-in values.
+func newResource(ctx context.Context, cfg config.TelemetryConfig) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version.BuildVersion),
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentName(cfg.Environment))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attrs...),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if errors.Is(err, resource.ErrPartialResource) || errors.Is(err, resource.ErrSchemaURLConflict) {
+		// Keep whatever attributes were detected rather than failing startup
+		slog.Warn("tracing resource detection incomplete", "error", err)
+		return res, nil
+	}
+	return res, err
+}
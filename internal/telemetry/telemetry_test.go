@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"sportsagent/internal/config"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupDisabledWithoutExporter(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Default().Telemetry)
+	if err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown returned error: %v", err)
+	}
+}
+
+func TestExporterName(t *testing.T) {
+	tests := []struct {
+		exporter, endpoint, want string
+	}{
+		{"", "", "none"},
+		{"", "http://collector:4318", "otlp"},
+		{"none", "http://collector:4318", "none"},
+		{"console", "", "stdout"},
+		{"stdout", "", "stdout"},
+	}
+
+	for _, tt := range tests {
+		cfg := config.TelemetryConfig{Exporter: tt.exporter, OTLPEndpoint: tt.endpoint}
+		if got := exporterName(cfg); got != tt.want {
+			t.Errorf("exporterName(%q, %q) = %q, want %q", tt.exporter, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestSetupOTLPDoesNotConnectEagerly(t *testing.T) {
+	for _, protocol := range []string{"http/protobuf", "grpc"} {
+		t.Run(protocol, func(t *testing.T) {
+			cfg := config.Default().Telemetry
+			cfg.OTLPEndpoint = "http://127.0.0.1:1"
+			cfg.OTLPProtocol = protocol
+			cfg.OTLPHeaders = map[string]string{"authorization": "Bearer test"}
+
+			shutdown, err := Setup(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Setup returned error: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			shutdown(ctx)
+		})
+	}
+}
+
+func TestSetupRejectsMissingCAFile(t *testing.T) {
+	cfg := config.Default().Telemetry
+	cfg.OTLPEndpoint = "https://collector:4318"
+	cfg.TLS.CAFile = "does-not-exist.pem"
+
+	if _, err := Setup(context.Background(), cfg); err == nil {
+		t.Fatal("expected error for missing CA file")
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	traceID := trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	params := sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: traceID, Name: "test"}
+
+	tests := []struct {
+		sampler string
+		arg     float64
+		want    sdktrace.SamplingDecision
+	}{
+		{"always_on", 0, sdktrace.RecordAndSample},
+		{"always_off", 0, sdktrace.Drop},
+		{"traceidratio", 0, sdktrace.Drop},
+		{"parentbased_traceidratio", 1, sdktrace.RecordAndSample},
+		{"parentbased_always_off", 0, sdktrace.Drop},
+	}
+
+	for _, tt := range tests {
+		sampler := newSampler(config.TelemetryConfig{Sampler: tt.sampler, SamplerArg: tt.arg})
+		if got := sampler.ShouldSample(params).Decision; got != tt.want {
+			t.Errorf("%s(%v) decision = %v, want %v", tt.sampler, tt.arg, got, tt.want)
+		}
+	}
+}
@@ -55,9 +55,12 @@ func ConvertOpenAPIToTools(specs []ServiceSpec) []openai.ChatCompletionToolUnion
 
 func buildToolMetadata(service, path, method string, pathItem *openapi3.PathItem, operation *openapi3.Operation) ToolMetadata {
 	metadata := ToolMetadata{
-		Service: service,
-		Method:  strings.ToUpper(method),
-		Path:    path,
+		Service:     service,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Tags:        operation.Tags,
 	}
 
 	for _, param := range collectParameters(pathItem, operation) {
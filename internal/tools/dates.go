@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"sportsagent/internal/calendar"
+
+	"github.com/openai/openai-go/v3"
+)
+
+var (
+	calendarMu     sync.RWMutex
+	leagueCalendar *calendar.Calendar
+)
+
+// SetCalendar sets the calendar behind the resolve_dates tool. Without one the built-in
+// calendar is used in UTC.
+func SetCalendar(c *calendar.Calendar) {
+	calendarMu.Lock()
+	leagueCalendar = c
+	calendarMu.Unlock()
+}
+
+func currentCalendar() *calendar.Calendar {
+	calendarMu.RLock()
+	c := leagueCalendar
+	calendarMu.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	c, err := calendar.Load("", "UTC")
+	if err != nil {
+		slog.Error("failed to load the built-in calendar", "error", err)
+		return nil
+	}
+	SetCalendar(c)
+	return c
+}
+
+var resolveDatesTool = localTool{
+	definition: openai.FunctionDefinitionParam{
+		Name: "resolve_dates",
+		Description: openai.String("Current date and time, and the exact dates of relative expressions such as \"tonight\", \"this weekend\" or \"Week 12\". " +
+			"Call this before passing dates to other tools. With a league, also gives the season, phase, week number and game days."),
+		Parameters: openai.FunctionParameters{
+			"type": "object",
+			"properties": map[string]any{
+				"expression": map[string]any{
+					"type": "string",
+					"description": `What to resolve: "today", "tonight", "tomorrow", "yesterday", "sunday", "next monday", "last friday", ` +
+						`"this/next/last week", "this/next/last weekend", "this/next/last month", "next 3 days", "last 7 days", ` +
+						`"in 2 days", "3 days ago", "2026-10-19" or "week 12" (needs league). Defaults to today.`,
+				},
+				"league": map[string]any{
+					"type":        "string",
+					"description": `League whose calendar to use, e.g. "nfl". Weeks are then the league's numbered weeks.`,
+				},
+				"season": map[string]any{
+					"type":        "string",
+					"description": `Season of "week N", e.g. "2026"; defaults to the current or next season`,
+				},
+			},
+		},
+	},
+	tags: []string{"date", "time", "today", "tonight", "weekend", "week", "calendar", "season", "schedule"},
+	run:  resolveDates,
+}
+
+// dateRange is a resolved range. Dates are YYYY-MM-DD and times RFC 3339, to exclusive.
+type dateRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type leagueWeek struct {
+	League    string   `json:"league"`
+	Season    string   `json:"season,omitempty"`
+	Phase     string   `json:"phase"`
+	Week      int      `json:"week,omitempty"`
+	WeekStart string   `json:"weekStart,omitempty"`
+	WeekEnd   string   `json:"weekEnd,omitempty"`
+	GameDays  []string `json:"gameDays,omitempty"`
+}
+
+func resolveDates(args map[string]interface{}) (any, error) {
+	c := currentCalendar()
+	if c == nil {
+		return nil, errors.New("no calendar is loaded")
+	}
+	expression, _ := args["expression"].(string)
+	league, _ := args["league"].(string)
+	season, _ := args["season"].(string)
+
+	now := c.Now()
+	r, err := c.Resolve(expression, league, season, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := struct {
+		Now      string      `json:"now"`
+		Timezone string      `json:"timezone"`
+		Today    string      `json:"today"`
+		Weekday  string      `json:"weekday"`
+		Range    dateRange   `json:"range"`
+		Days     int         `json:"days"`
+		League   *leagueWeek `json:"league,omitempty"`
+	}{
+		Now:      now.Format(time.RFC3339),
+		Timezone: c.Location().String(),
+		Today:    now.Format(time.DateOnly),
+		Weekday:  now.Weekday().String(),
+		Range: dateRange{
+			Start: r.Start.Format(time.DateOnly),
+			End:   r.End.Format(time.DateOnly),
+			From:  r.From.Format(time.RFC3339),
+			To:    r.To.Format(time.RFC3339),
+		},
+		Days: int(r.End.Sub(r.Start).Hours()/24+0.5) + 1,
+	}
+	if strings.TrimSpace(league) != "" {
+		lw, err := c.LeagueWeek(league, r.Start)
+		if err != nil {
+			return nil, err
+		}
+		result.League = &leagueWeek{
+			League: lw.League,
+			Season: lw.Season,
+			Phase:  lw.Phase,
+			Week:   lw.Week,
+		}
+		if lw.Week > 0 {
+			result.League.WeekStart = lw.WeekStart.Format(time.DateOnly)
+			result.League.WeekEnd = lw.WeekEnd.Format(time.DateOnly)
+			for _, day := range lw.GameDays {
+				result.League.GameDays = append(result.League.GameDays, day.Format(time.DateOnly))
+			}
+		}
+	}
+	return result, nil
+}
@@ -3,9 +3,10 @@ package tools
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"strings"
+	"sync"
 
 	"github.com/openai/openai-go/v3"
 )
@@ -15,34 +16,52 @@ const (
 	ServiceOddsTracker = "oddstracker"
 )
 
-// GetTools loads OpenAI function tools from OpenAPI specs of external services
-// Falls back to hardcoded definitions if OpenAPI specs are unavailable
-func GetTools() []openai.ChatCompletionToolUnionParam {
-	return GetToolsWithContext(context.Background())
+const (
+	SourceOpenAPI  = "openapi"
+	SourceFallback = "fallback"
+)
+
+var (
+	toolSourceMu     sync.RWMutex
+	toolSource       string
+	toolSourceReason string
+)
+
+// LoadedSource reports whether the current tools came from OpenAPI specs or the
+// hardcoded fallback, and the reason for falling back if any. It is empty before
+// tools are loaded.
+func LoadedSource() (source string, reason string) {
+	toolSourceMu.RLock()
+	defer toolSourceMu.RUnlock()
+	return toolSource, toolSourceReason
 }
 
-// GetToolsWithContext loads tools with a custom context (useful for timeouts)
-func GetToolsWithContext(ctx context.Context) []openai.ChatCompletionToolUnionParam {
-	// Get service URLs from environment or use defaults
-	rotoreaderURL := os.Getenv("ROTOREADER_SERVICE_URL")
-	if rotoreaderURL == "" {
-		rotoreaderURL = "http://localhost:8081"
-	}
+func setLoadedSource(source, reason string) {
+	toolSourceMu.Lock()
+	toolSource, toolSourceReason = source, reason
+	toolSourceMu.Unlock()
+}
 
-	oddstrackerURL := os.Getenv("ODDSTRACKER_SERVICE_URL")
-	if oddstrackerURL == "" {
-		oddstrackerURL = "http://localhost:8082"
+// SpecSources returns the OpenAPI spec locations for the given service base URLs
+func SpecSources(rotoreaderURL, oddstrackerURL string) []SpecSource {
+	return []SpecSource{
+		{Service: ServiceRotoReader, URL: fmt.Sprintf("%s/openapi.json", strings.TrimRight(rotoreaderURL, "/"))},
+		{Service: ServiceOddsTracker, URL: fmt.Sprintf("%s/openapi.json", strings.TrimRight(oddstrackerURL, "/"))},
 	}
+}
 
-	// Try to load OpenAPI specs from services
-	sources := []SpecSource{
-		{Service: ServiceRotoReader, URL: fmt.Sprintf("%s/openapi.json", rotoreaderURL)},
-		{Service: ServiceOddsTracker, URL: fmt.Sprintf("%s/openapi.json", oddstrackerURL)},
-	}
+// GetTools loads OpenAI function tools from OpenAPI specs of external services
+// Falls back to hardcoded definitions if OpenAPI specs are unavailable
+func GetTools(sources []SpecSource) []openai.ChatCompletionToolUnionParam {
+	return GetToolsWithContext(context.Background(), sources)
+}
 
+// GetToolsWithContext loads tools with a custom context (useful for timeouts)
+func GetToolsWithContext(ctx context.Context, sources []SpecSource) []openai.ChatCompletionToolUnionParam {
 	specs, err := LoadMultipleSpecs(ctx, sources)
 	if err != nil {
-		log.Printf("Warning: Failed to load OpenAPI specs, falling back to hardcoded definitions: %v", err)
+		slog.WarnContext(ctx, "failed to load OpenAPI specs, falling back to hardcoded definitions", "error", err)
+		setLoadedSource(SourceFallback, err.Error())
 		return getFallbackTools()
 	}
 
@@ -50,30 +69,38 @@ func GetToolsWithContext(ctx context.Context) []openai.ChatCompletionToolUnionPa
 	tools := ConvertOpenAPIToTools(specs)
 
 	if len(tools) == 0 {
-		log.Printf("Warning: No tools found in OpenAPI specs, falling back to hardcoded definitions")
+		slog.WarnContext(ctx, "no tools found in OpenAPI specs, falling back to hardcoded definitions")
+		setLoadedSource(SourceFallback, "no tools found in OpenAPI specs")
 		return getFallbackTools()
 	}
 
-	log.Printf("Successfully loaded %d tools from OpenAPI specs", len(tools))
-	return tools
+	slog.InfoContext(ctx, "loaded tools from OpenAPI specs", "count", len(tools))
+	setLoadedSource(SourceOpenAPI, "")
+	setServices(append(servicesFromSpecs(specs), localService))
+	return withWorkflows(withLocalTools(tools))
 }
 
 // getFallbackTools returns hardcoded tool definitions as a fallback
 func getFallbackTools() []openai.ChatCompletionToolUnionParam {
 	resetToolMetadata()
+	setServices(append(append([]ServiceInfo(nil), fallbackServices...), localService))
 
 	registerToolMetadata("get_roto_data", ToolMetadata{
 		Service: ServiceRotoReader,
 		Method:  http.MethodGet,
 		Path:    "/feed",
+		Summary: "Get the latest sports news feed from rotoreader",
+		Tags:    []string{"news"},
 	})
 	registerToolMetadata("get_odds_data", ToolMetadata{
 		Service: ServiceOddsTracker,
 		Method:  http.MethodGet,
 		Path:    "/changes",
+		Summary: "Get recent betting odds changes from oddstracker",
+		Tags:    []string{"odds"},
 	})
 
-	return []openai.ChatCompletionToolUnionParam{
+	return withWorkflows(withLocalTools([]openai.ChatCompletionToolUnionParam{
 		openai.ChatCompletionFunctionTool(openai.FunctionDefinitionParam{
 			Name:        "get_roto_data",
 			Description: openai.String("Get the latest sports news feed from rotoreader"),
@@ -90,5 +117,16 @@ func getFallbackTools() []openai.ChatCompletionToolUnionParam {
 				"properties": map[string]any{},
 			},
 		}),
+	}))
+}
+
+// FindTool returns the function definition with the given name from a tool list
+func FindTool(tools []openai.ChatCompletionToolUnionParam, name string) (openai.FunctionDefinitionParam, bool) {
+	for _, tool := range tools {
+		fn := tool.GetFunction()
+		if fn != nil && fn.Name == name {
+			return *fn, true
+		}
 	}
+	return openai.FunctionDefinitionParam{}, false
 }
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"sportsagent/internal/entities"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// resolveLimit is the most matches resolve_entity returns
+const resolveLimit = 5
+
+var (
+	entitiesMu sync.RWMutex
+	resolver   *entities.Resolver
+)
+
+// SetEntities sets the resolver behind the resolve_entity tool. Without one the built-in
+// dictionary is used.
+func SetEntities(r *entities.Resolver) {
+	entitiesMu.Lock()
+	resolver = r
+	entitiesMu.Unlock()
+}
+
+func currentEntities() *entities.Resolver {
+	entitiesMu.RLock()
+	r := resolver
+	entitiesMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	r, err := entities.Load("", entities.DefaultMinScore)
+	if err != nil {
+		slog.Error("failed to load the built-in entities", "error", err)
+		return nil
+	}
+	SetEntities(r)
+	return r
+}
+
+var resolveEntityTool = localTool{
+	definition: openai.FunctionDefinitionParam{
+		Name: "resolve_entity",
+		Description: openai.String("Identify the player or team a name refers to, tolerating nicknames, abbreviations and misspellings, " +
+			"and give how each service spells it. Use it to match names across results from different services."),
+		Parameters: openai.FunctionParameters{
+			"type": "object",
+			"properties": map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": `Name to resolve, e.g. "Pat Mahomes" or "KC"`,
+				},
+				"type": map[string]any{
+					"type":        "string",
+					"enum":        []string{entities.Team, entities.Player},
+					"description": "Only match this kind of entity",
+				},
+			},
+			"required": []string{"name"},
+		},
+	},
+	tags: []string{"player", "team", "name", "alias", "abbreviation", "match", "entity"},
+	run:  resolveEntity,
+}
+
+type entityMatch struct {
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Name         string            `json:"name"`
+	Abbreviation string            `json:"abbreviation,omitempty"`
+	League       string            `json:"league,omitempty"`
+	Team         string            `json:"team,omitempty"`
+	Score        string            `json:"score"`
+	MatchedAlias string            `json:"matchedAlias"`
+	Spellings    map[string]string `json:"spellings"`
+}
+
+func resolveEntity(args map[string]interface{}) (any, error) {
+	r := currentEntities()
+	if r == nil {
+		return nil, errors.New("no entity dictionary is loaded")
+	}
+	text, _ := args["name"].(string)
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("name must not be empty")
+	}
+	entityType, _ := args["type"].(string)
+
+	result := struct {
+		Name    string        `json:"name"`
+		Matches []entityMatch `json:"matches"`
+		Note    string        `json:"note,omitempty"`
+	}{Name: text, Matches: []entityMatch{}}
+
+	matches := r.Resolve(text, entityType, resolveLimit)
+	for _, m := range matches {
+		e := m.Entity
+		match := entityMatch{
+			ID:           e.ID,
+			Type:         e.Type,
+			Name:         e.Name,
+			Abbreviation: e.Abbreviation,
+			League:       e.League,
+			Score:        fmt.Sprintf("%.2f", m.Score),
+			MatchedAlias: m.Alias,
+			Spellings:    map[string]string{},
+		}
+		if team, ok := r.Get(e.Team); ok {
+			match.Team = team.Name
+		}
+		for _, service := range Services() {
+			if service.Name != ServiceLocal {
+				match.Spellings[service.Name] = r.Spelling(e, service.Name)
+			}
+		}
+		result.Matches = append(result.Matches, match)
+	}
+	switch {
+	case len(matches) == 0:
+		result.Note = "no known player or team matches this name"
+	case len(matches) > 1:
+		if _, ok := r.Identify(text, entityType); !ok {
+			result.Note = "the name is ambiguous: ask which one is meant or use more context"
+		}
+	}
+	return result, nil
+}
@@ -209,7 +209,7 @@ func TestGetTools(t *testing.T) {
 	}
 
 	// Test the main GetTools function
-	tools := GetTools()
+	tools := GetTools(SpecSources("http://localhost:8081", "http://localhost:8082"))
 
 	if len(tools) == 0 {
 		t.Fatal("GetTools returned no tools")
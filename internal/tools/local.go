@@ -0,0 +1,481 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sportsagent/internal/oddsmath"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// ServiceLocal is the service of tools computed in-process rather than by a backend
+const ServiceLocal = "local"
+
+// MethodLocal is the method reported for local tools, which make no HTTP request
+const MethodLocal = "LOCAL"
+
+// localService describes the local tools to the model
+var localService = ServiceInfo{
+	Name:        ServiceLocal,
+	Title:       "Built-in tools",
+	Description: "Exact odds conversion, implied and no-vig probability, parlay pricing, expected value and Kelly stakes; the current date, relative dates and league weeks; player and team name resolution",
+}
+
+// localTool is a tool implemented in Go
+type localTool struct {
+	definition openai.FunctionDefinitionParam
+	tags       []string
+	run        func(args map[string]interface{}) (any, error)
+}
+
+var oddsParam = map[string]any{
+	"type":        []string{"string", "number"},
+	"description": `Odds as American ("-110", "+150"), decimal ("1.91") or fractional ("10/11")`,
+}
+
+var oddsListParam = map[string]any{
+	"type":     "array",
+	"items":    oddsParam,
+	"minItems": 2,
+}
+
+var probabilityParam = map[string]any{
+	"type":        []string{"string", "number"},
+	"description": `Your estimated probability of the outcome, between 0 and 1 or as a percentage ("55%")`,
+}
+
+var localTools = []localTool{
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "convert_odds",
+			Description: openai.String("Convert odds between American, decimal and fractional formats and give the implied probability"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"odds": oddsParam,
+					"format": map[string]any{
+						"type":        "string",
+						"enum":        []string{oddsmath.American, oddsmath.Decimal, oddsmath.Fractional},
+						"description": "Format of odds; detected when omitted",
+					},
+				},
+				"required": []string{"odds"},
+			},
+		},
+		tags: []string{"odds", "conversion", "american", "decimal", "fractional", "probability"},
+		run:  convertOdds,
+	},
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "implied_probability",
+			Description: openai.String("Implied probability of each outcome's odds, with the market's overround and hold when all outcomes are given"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"odds": map[string]any{"type": "array", "items": oddsParam, "minItems": 1},
+				},
+				"required": []string{"odds"},
+			},
+		},
+		tags: []string{"odds", "probability", "vig", "juice", "overround"},
+		run:  impliedProbability,
+	},
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "no_vig_odds",
+			Description: openai.String("Remove the bookmaker's margin from a market to get fair probabilities and odds for each outcome"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"odds": oddsListParam,
+				},
+				"required": []string{"odds"},
+			},
+		},
+		tags: []string{"odds", "vig", "juice", "fair", "probability", "margin"},
+		run:  noVigOdds,
+	},
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "parlay_odds",
+			Description: openai.String("Price a parlay from the odds of its legs, with the payout for a stake"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"legs":  oddsListParam,
+					"stake": map[string]any{"type": []string{"string", "number"}, "description": "Amount staked; defaults to 100"},
+				},
+				"required": []string{"legs"},
+			},
+		},
+		tags: []string{"odds", "parlay", "accumulator", "payout"},
+		run:  parlayOdds,
+	},
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "expected_value",
+			Description: openai.String("Expected profit of a bet at the given odds for an estimated win probability"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"odds":        oddsParam,
+					"probability": probabilityParam,
+					"stake":       map[string]any{"type": []string{"string", "number"}, "description": "Amount staked; defaults to 100"},
+				},
+				"required": []string{"odds", "probability"},
+			},
+		},
+		tags: []string{"odds", "expected", "value", "ev", "edge"},
+		run:  expectedValue,
+	},
+	{
+		definition: openai.FunctionDefinitionParam{
+			Name:        "kelly_stake",
+			Description: openai.String("Kelly criterion stake for a bet at the given odds and estimated win probability"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"odds":        oddsParam,
+					"probability": probabilityParam,
+					"bankroll":    map[string]any{"type": []string{"string", "number"}, "description": "Bankroll to size the stake from"},
+					"fraction":    map[string]any{"type": []string{"string", "number"}, "description": "Fraction of full Kelly to stake, e.g. 0.5; defaults to 1"},
+				},
+				"required": []string{"odds", "probability"},
+			},
+		},
+		tags: []string{"kelly", "stake", "bankroll", "sizing", "edge"},
+		run:  kellyStake,
+	},
+	resolveDatesTool,
+	resolveEntityTool,
+}
+
+// withLocalTools appends the local tools to the loaded ones and registers their metadata.
+// A loaded tool with the same name takes precedence.
+func withLocalTools(loaded []openai.ChatCompletionToolUnionParam) []openai.ChatCompletionToolUnionParam {
+	for _, tool := range localTools {
+		name := tool.definition.Name
+		if _, exists := FindTool(loaded, name); exists {
+			slog.Warn("a loaded tool shadows a local tool", "tool", name)
+			continue
+		}
+		registerToolMetadata(name, ToolMetadata{
+			Service: ServiceLocal,
+			Method:  MethodLocal,
+			Path:    "/" + name,
+			Summary: tool.definition.Description.Value,
+			Tags:    tool.tags,
+		})
+		loaded = append(loaded, openai.ChatCompletionFunctionTool(tool.definition))
+	}
+	return loaded
+}
+
+// ReadOnly reports whether a tool leaves backend state unchanged: local tools, GET, HEAD
+// and OPTIONS operations, and workflows whose steps are all read-only
+func ReadOnly(metadata ToolMetadata) bool {
+	switch metadata.Service {
+	case ServiceLocal:
+		return true
+	case ServiceWorkflow:
+		for _, step := range metadata.Steps {
+			if stepMetadata, ok := GetToolMetadata(step); !ok || !ReadOnly(stepMetadata) {
+				return false
+			}
+		}
+		return true
+	}
+	switch strings.ToUpper(metadata.Method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunLocal executes a local tool and returns its result as JSON. Bad arguments wrap
+// ErrInvalidArguments.
+func RunLocal(name string, args map[string]interface{}) (string, error) {
+	for _, tool := range localTools {
+		if tool.definition.Name != name {
+			continue
+		}
+		result, err := tool.run(args)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("no local tool %s", name)
+}
+
+// price is a price in every format. Numbers are strings rounded from the exact values so
+// the model quotes them rather than recomputing.
+type price struct {
+	American           string `json:"american"`
+	Decimal            string `json:"decimal"`
+	Fractional         string `json:"fractional"`
+	ImpliedProbability string `json:"impliedProbability"`
+}
+
+func newPrice(odds oddsmath.Odds) price {
+	return price{
+		American:           oddsmath.FormatAmerican(odds.American()),
+		Decimal:            oddsmath.Format(odds.Decimal(), 4),
+		Fractional:         oddsmath.FormatFractional(odds),
+		ImpliedProbability: oddsmath.Format(odds.ImpliedProbability(), 4),
+	}
+}
+
+func convertOdds(args map[string]interface{}) (any, error) {
+	format, _ := args["format"].(string)
+	odds, err := oddsArg(args["odds"], format)
+	if err != nil {
+		return nil, err
+	}
+	return newPrice(odds), nil
+}
+
+func impliedProbability(args map[string]interface{}) (any, error) {
+	outcomes, err := oddsListArg(args["odds"], 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := struct {
+		Outcomes  []string `json:"impliedProbabilities"`
+		Overround string   `json:"overround,omitempty"`
+		Hold      string   `json:"hold,omitempty"`
+	}{}
+	for _, odds := range outcomes {
+		result.Outcomes = append(result.Outcomes, oddsmath.Format(odds.ImpliedProbability(), 4))
+	}
+	if len(outcomes) > 1 {
+		market, _ := oddsmath.NewMarket(outcomes)
+		result.Overround = oddsmath.Format(market.Overround, 4)
+		result.Hold = oddsmath.Format(market.Hold(), 4)
+	}
+	return result, nil
+}
+
+func noVigOdds(args map[string]interface{}) (any, error) {
+	outcomes, err := oddsListArg(args["odds"], 2)
+	if err != nil {
+		return nil, err
+	}
+	market, err := oddsmath.NewMarket(outcomes)
+	if err != nil {
+		return nil, err
+	}
+
+	type fairOutcome struct {
+		Input string `json:"input"`
+		price
+	}
+	result := struct {
+		Outcomes  []fairOutcome `json:"outcomes"`
+		Overround string        `json:"overround"`
+		Hold      string        `json:"hold"`
+	}{
+		Overround: oddsmath.Format(market.Overround, 4),
+		Hold:      oddsmath.Format(market.Hold(), 4),
+	}
+	for i, p := range market.Fair() {
+		fair, err := oddsmath.FromProbability(p)
+		if err != nil {
+			return nil, err
+		}
+		input, _ := decimalString(args["odds"].([]interface{})[i])
+		result.Outcomes = append(result.Outcomes, fairOutcome{Input: input, price: newPrice(fair)})
+	}
+	return result, nil
+}
+
+func parlayOdds(args map[string]interface{}) (any, error) {
+	legs, err := oddsListArg(args["legs"], 2)
+	if err != nil {
+		return nil, err
+	}
+	stake, err := amountArg(args, "stake", big.NewRat(100, 1))
+	if err != nil {
+		return nil, err
+	}
+	parlay, err := oddsmath.Parlay(legs)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		price
+		Stake  string `json:"stake"`
+		Payout string `json:"payout"`
+		Profit string `json:"profit"`
+	}{
+		price:  newPrice(parlay),
+		Stake:  oddsmath.Format(stake, 2),
+		Payout: oddsmath.Format(new(big.Rat).Mul(stake, parlay.Decimal()), 2),
+		Profit: oddsmath.Format(new(big.Rat).Mul(stake, parlay.Profit()), 2),
+	}, nil
+}
+
+func expectedValue(args map[string]interface{}) (any, error) {
+	odds, err := oddsArg(args["odds"], "")
+	if err != nil {
+		return nil, err
+	}
+	p, err := probabilityArg(args["probability"])
+	if err != nil {
+		return nil, err
+	}
+	stake, err := amountArg(args, "stake", big.NewRat(100, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	ev := oddsmath.ExpectedValue(odds, p, stake)
+	// Edge is the expected profit per unit staked
+	edge := new(big.Rat).Quo(ev, stake)
+	return struct {
+		Stake              string `json:"stake"`
+		ExpectedValue      string `json:"expectedValue"`
+		Edge               string `json:"edge"`
+		ImpliedProbability string `json:"impliedProbability"`
+		Probability        string `json:"probability"`
+	}{
+		Stake:              oddsmath.Format(stake, 2),
+		ExpectedValue:      oddsmath.Format(ev, 2),
+		Edge:               oddsmath.Format(edge, 4),
+		ImpliedProbability: oddsmath.Format(odds.ImpliedProbability(), 4),
+		Probability:        oddsmath.Format(p, 4),
+	}, nil
+}
+
+func kellyStake(args map[string]interface{}) (any, error) {
+	odds, err := oddsArg(args["odds"], "")
+	if err != nil {
+		return nil, err
+	}
+	p, err := probabilityArg(args["probability"])
+	if err != nil {
+		return nil, err
+	}
+	fraction, err := amountArg(args, "fraction", big.NewRat(1, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	kelly := oddsmath.Kelly(odds, p)
+	result := struct {
+		FullKelly string `json:"fullKelly"`
+		Fraction  string `json:"fraction"`
+		Stake     string `json:"stakeFraction"`
+		Amount    string `json:"stake,omitempty"`
+		Note      string `json:"note,omitempty"`
+	}{
+		FullKelly: oddsmath.Format(kelly, 4),
+		Fraction:  oddsmath.Format(fraction, 4),
+	}
+	stake := new(big.Rat).Mul(kelly, fraction)
+	if kelly.Sign() <= 0 {
+		stake.SetInt64(0)
+		result.Note = "no edge at these odds: do not bet"
+	}
+	result.Stake = oddsmath.Format(stake, 4)
+	if _, ok := args["bankroll"]; ok {
+		bankroll, err := amountArg(args, "bankroll", nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Amount = oddsmath.Format(new(big.Rat).Mul(stake, bankroll), 2)
+	}
+	return result, nil
+}
+
+// decimalString returns a JSON number or string argument as decimal text. Numbers are
+// formatted with the fewest digits that round-trip, so 1.91 stays 1.91.
+func decimalString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", fmt.Errorf("missing value")
+	default:
+		return "", fmt.Errorf("expected a number or string, got %T", value)
+	}
+}
+
+func oddsArg(value interface{}, format string) (oddsmath.Odds, error) {
+	s, err := decimalString(value)
+	if err != nil {
+		return oddsmath.Odds{}, fmt.Errorf("odds: %v", err)
+	}
+	// A JSON number loses the sign of positive American odds; the format detection
+	// treats unsigned values of 100 or more as American
+	return oddsmath.Parse(s, format)
+}
+
+func oddsListArg(value interface{}, min int) ([]oddsmath.Odds, error) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) < min {
+		return nil, fmt.Errorf("expected a list of at least %d odds", min)
+	}
+	outcomes := make([]oddsmath.Odds, len(list))
+	for i, item := range list {
+		odds, err := oddsArg(item, "")
+		if err != nil {
+			return nil, err
+		}
+		outcomes[i] = odds
+	}
+	return outcomes, nil
+}
+
+func probabilityArg(value interface{}) (*big.Rat, error) {
+	s, err := decimalString(value)
+	if err != nil {
+		return nil, fmt.Errorf("probability: %v", err)
+	}
+	percent := strings.HasSuffix(s, "%")
+	p, ok := new(big.Rat).SetString(strings.TrimSpace(strings.TrimSuffix(s, "%")))
+	if !ok {
+		return nil, fmt.Errorf("probability: %q is not a number", s)
+	}
+	if percent {
+		p.Quo(p, big.NewRat(100, 1))
+	}
+	if p.Sign() <= 0 || p.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf("probability must be between 0 and 1 exclusive, got %s", s)
+	}
+	return p, nil
+}
+
+// amountArg reads an optional positive amount, returning def when it is absent
+func amountArg(args map[string]interface{}, name string, def *big.Rat) (*big.Rat, error) {
+	value, ok := args[name]
+	if !ok {
+		return def, nil
+	}
+	s, err := decimalString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%s must be a positive number, got %q", name, s)
+	}
+	return r, nil
+}
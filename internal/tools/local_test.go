@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"sportsagent/internal/calendar"
+	"sportsagent/internal/entities"
+)
+
+func TestRunLocal(t *testing.T) {
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "convert American number",
+			tool: "convert_odds",
+			args: map[string]interface{}{"odds": float64(-110)},
+			want: map[string]interface{}{"american": "-110", "decimal": "1.9091", "fractional": "10/11", "impliedProbability": "0.5238"},
+		},
+		{
+			name: "convert decimal with format",
+			tool: "convert_odds",
+			args: map[string]interface{}{"odds": "2.5", "format": "decimal"},
+			want: map[string]interface{}{"american": "+150", "fractional": "3/2"},
+		},
+		{
+			name: "parlay payout",
+			tool: "parlay_odds",
+			args: map[string]interface{}{"legs": []interface{}{"+100", "+100"}, "stake": float64(10)},
+			want: map[string]interface{}{"decimal": "4", "payout": "40", "profit": "30"},
+		},
+		{
+			name: "expected value from percentage",
+			tool: "expected_value",
+			args: map[string]interface{}{"odds": "+150", "probability": "45%"},
+			want: map[string]interface{}{"expectedValue": "12.5", "edge": "0.125"},
+		},
+		{
+			name: "fractional Kelly with bankroll",
+			tool: "kelly_stake",
+			args: map[string]interface{}{"odds": "+150", "probability": 0.45, "fraction": 0.5, "bankroll": float64(1200)},
+			want: map[string]interface{}{"fullKelly": "0.0833", "stakeFraction": "0.0417", "stake": "50"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, _ := FindTool(withLocalTools(nil), tt.tool)
+			if err := ValidateArguments(definition.Parameters, tt.args); err != nil {
+				t.Fatalf("arguments rejected by schema: %v", err)
+			}
+
+			output, err := RunLocal(tt.tool, tt.args)
+			if err != nil {
+				t.Fatalf("RunLocal: %v", err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(output), &got); err != nil {
+				t.Fatalf("output is not JSON: %v\n%s", err, output)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %v, want %v\n%s", key, got[key], want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestRunLocal_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{tool: "convert_odds", args: map[string]interface{}{"odds": "-50"}},
+		{tool: "no_vig_odds", args: map[string]interface{}{"odds": []interface{}{"-110"}}},
+		{tool: "expected_value", args: map[string]interface{}{"odds": "+150", "probability": 1.5}},
+		{tool: "parlay_odds", args: map[string]interface{}{"legs": []interface{}{"+100", "+100"}, "stake": float64(-5)}},
+	}
+
+	for _, tt := range tests {
+		if _, err := RunLocal(tt.tool, tt.args); !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("RunLocal(%s, %v) = %v, want ErrInvalidArguments", tt.tool, tt.args, err)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		metadata ToolMetadata
+		want     bool
+	}{
+		{metadata: ToolMetadata{Service: ServiceLocal, Method: MethodLocal}, want: true},
+		{metadata: ToolMetadata{Service: "oddstracker", Method: "get"}, want: true},
+		{metadata: ToolMetadata{Service: "oddstracker", Method: "POST"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := ReadOnly(tt.metadata); got != tt.want {
+			t.Errorf("ReadOnly(%+v) = %v, want %v", tt.metadata, got, tt.want)
+		}
+	}
+}
+
+func TestRunLocal_ResolveDates(t *testing.T) {
+	c, err := calendar.Load("", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetCalendar(c)
+	t.Cleanup(func() { SetCalendar(nil) })
+
+	args := map[string]interface{}{"expression": "week 1", "league": "nfl", "season": "2026"}
+	output, err := RunLocal("resolve_dates", args)
+	if err != nil {
+		t.Fatalf("RunLocal: %v", err)
+	}
+
+	var got struct {
+		Timezone string `json:"timezone"`
+		Range    struct {
+			Start string `json:"start"`
+			End   string `json:"end"`
+			From  string `json:"from"`
+		} `json:"range"`
+		Days   int `json:"days"`
+		League struct {
+			Week     int      `json:"week"`
+			Phase    string   `json:"phase"`
+			GameDays []string `json:"gameDays"`
+		} `json:"league"`
+	}
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output)
+	}
+	if got.Timezone != "America/New_York" || got.Range.Start != "2026-09-08" || got.Range.End != "2026-09-14" || got.Days != 7 {
+		t.Fatalf("unexpected range\n%s", output)
+	}
+	if got.Range.From != "2026-09-08T00:00:00-04:00" {
+		t.Errorf("from = %s, want midnight in New York", got.Range.From)
+	}
+	if got.League.Week != 1 || got.League.Phase != "regular season" || len(got.League.GameDays) != 3 {
+		t.Errorf("unexpected league week\n%s", output)
+	}
+
+	if _, err := RunLocal("resolve_dates", map[string]interface{}{"expression": "whenever"}); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("expected ErrInvalidArguments for an unknown expression, got %v", err)
+	}
+}
+
+func TestRunLocal_ResolveEntity(t *testing.T) {
+	r, err := entities.Load("", entities.DefaultMinScore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetEntities(r)
+	t.Cleanup(func() { SetEntities(nil) })
+
+	output, err := RunLocal("resolve_entity", map[string]interface{}{"name": "Kansas Cty Chiefs", "type": "team"})
+	if err != nil {
+		t.Fatalf("RunLocal: %v", err)
+	}
+	var got struct {
+		Matches []struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			Score string `json:"score"`
+		} `json:"matches"`
+		Note string `json:"note"`
+	}
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output)
+	}
+	if len(got.Matches) == 0 || got.Matches[0].ID != "kc" || got.Note != "" {
+		t.Fatalf("expected a single confident match for the Chiefs\n%s", output)
+	}
+
+	output, _ = RunLocal("resolve_entity", map[string]interface{}{"name": "New York"})
+	if !strings.Contains(output, "ambiguous") {
+		t.Errorf("expected New York to be reported as ambiguous\n%s", output)
+	}
+}
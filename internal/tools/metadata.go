@@ -21,18 +21,24 @@ const (
 )
 
 type ParameterDefinition struct {
-	Name     string
-	In       ParameterLocation
-	Required bool
+	Name     string            `json:"name"`
+	In       ParameterLocation `json:"in"`
+	Required bool              `json:"required"`
 }
 
 type ToolMetadata struct {
-	Service     string
-	Method      string
-	Path        string
-	PathParams  []ParameterDefinition
-	QueryParams []ParameterDefinition
-	HasJSONBody bool
+	Service     string                `json:"service"`
+	Method      string                `json:"method"`
+	Path        string                `json:"path"`
+	PathParams  []ParameterDefinition `json:"pathParams,omitempty"`
+	QueryParams []ParameterDefinition `json:"queryParams,omitempty"`
+	HasJSONBody bool                  `json:"hasJsonBody,omitempty"`
+	// Summary, Description and Tags come from the operation and are used to rank tools
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Steps are the tools a workflow calls
+	Steps []string `json:"steps,omitempty"`
 }
 
 var (
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+)
+
+// ServiceInfo describes a backend service from the info block of its OpenAPI spec
+type ServiceInfo struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// fallbackServices describe the services when their specs could not be loaded
+var fallbackServices = []ServiceInfo{
+	{Name: ServiceRotoReader, Title: "RotoReader", Description: "Latest sports news feed"},
+	{Name: ServiceOddsTracker, Title: "OddsTracker", Description: "Recent betting odds changes"},
+}
+
+var (
+	serviceInfoMu sync.RWMutex
+	serviceInfo   []ServiceInfo
+)
+
+// Services returns the services behind the loaded tools, sorted by name. It is empty
+// before tools are loaded.
+func Services() []ServiceInfo {
+	serviceInfoMu.RLock()
+	defer serviceInfoMu.RUnlock()
+	return append([]ServiceInfo(nil), serviceInfo...)
+}
+
+func setServices(services []ServiceInfo) {
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	serviceInfoMu.Lock()
+	serviceInfo = services
+	serviceInfoMu.Unlock()
+}
+
+// servicesFromSpecs collects the info blocks of the loaded specs
+func servicesFromSpecs(specs []ServiceSpec) []ServiceInfo {
+	var services []ServiceInfo
+	for _, spec := range specs {
+		info := ServiceInfo{Name: spec.Service, Title: spec.Service}
+		if spec.Spec != nil && spec.Spec.Info != nil {
+			if spec.Spec.Info.Title != "" {
+				info.Title = spec.Spec.Info.Title
+			}
+			info.Description = spec.Spec.Info.Description
+			info.Version = spec.Spec.Info.Version
+		}
+		services = append(services, info)
+	}
+	return services
+}
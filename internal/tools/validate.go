@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/openai/openai-go/v3"
+)
+
+var ErrInvalidArguments = errors.New("invalid arguments")
+
+// ValidateArguments checks tool call arguments against the function's JSON Schema parameters
+func ValidateArguments(parameters openai.FunctionParameters, args map[string]interface{}) error {
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameter schema: %w", err)
+	}
+
+	schema := openapi3.NewSchema()
+	if err := schema.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("failed to parse parameter schema: %w", err)
+	}
+
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	if err := schema.VisitJSON(args, openapi3.MultiErrors()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+
+	return nil
+}
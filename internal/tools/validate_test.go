@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func TestValidateArguments(t *testing.T) {
+	parameters := openai.FunctionParameters{
+		"type": "object",
+		"properties": map[string]any{
+			"event_id": map[string]any{"type": "string"},
+			"limit":    map[string]any{"type": "integer", "minimum": 1},
+		},
+		"required": []string{"event_id"},
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid", args: map[string]interface{}{"event_id": "abc", "limit": float64(5)}},
+		{name: "missing required", args: map[string]interface{}{"limit": float64(5)}, wantErr: true},
+		{name: "wrong type", args: map[string]interface{}{"event_id": "abc", "limit": "five"}, wantErr: true},
+		{name: "below minimum", args: map[string]interface{}{"event_id": "abc", "limit": float64(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(parameters, tt.args)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArguments) {
+					t.Fatalf("expected ErrInvalidArguments, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
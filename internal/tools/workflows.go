@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"sportsagent/internal/workflows"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// ServiceWorkflow is the service of composite tools that chain other tools
+const ServiceWorkflow = "workflow"
+
+// MethodWorkflow is the method reported for workflows, which run their steps in-process
+const MethodWorkflow = "WORKFLOW"
+
+var workflowService = ServiceInfo{
+	Name:        ServiceWorkflow,
+	Title:       "Workflows",
+	Description: "Composite tools that run a fixed chain of the other tools in one call",
+}
+
+var (
+	workflowsMu sync.RWMutex
+	// configured are the workflows to register with the next tool load
+	configured []*workflows.Workflow
+	// registered are the workflows whose tools all loaded, by name
+	registered = map[string]*workflows.Workflow{}
+)
+
+// SetWorkflows sets the composite tools registered by the next GetTools
+func SetWorkflows(ws []*workflows.Workflow) {
+	workflowsMu.Lock()
+	configured = ws
+	workflowsMu.Unlock()
+}
+
+// Workflow returns a registered workflow by name
+func Workflow(name string) (*workflows.Workflow, bool) {
+	workflowsMu.RLock()
+	defer workflowsMu.RUnlock()
+	w, ok := registered[name]
+	return w, ok
+}
+
+// withWorkflows appends the configured workflows whose steps call loaded tools and
+// registers their metadata. Workflows cannot call other workflows, and a loaded tool with
+// the same name takes precedence.
+func withWorkflows(loaded []openai.ChatCompletionToolUnionParam) []openai.ChatCompletionToolUnionParam {
+	workflowsMu.Lock()
+	defer workflowsMu.Unlock()
+
+	registered = map[string]*workflows.Workflow{}
+	base := loaded
+	for _, w := range configured {
+		if _, exists := FindTool(loaded, w.Name); exists {
+			slog.Warn("a loaded tool shadows a workflow", "workflow", w.Name)
+			continue
+		}
+		var missing []string
+		for _, tool := range w.Tools() {
+			if _, ok := FindTool(base, tool); !ok {
+				missing = append(missing, tool)
+			}
+		}
+		if len(missing) > 0 {
+			slog.Warn("workflow skipped: its steps call unknown tools", "workflow", w.Name, "tools", missing)
+			continue
+		}
+
+		registerToolMetadata(w.Name, ToolMetadata{
+			Service: ServiceWorkflow,
+			Method:  MethodWorkflow,
+			Path:    "/" + w.Name,
+			Summary: w.Description,
+			Tags:    append([]string{"workflow"}, w.Tools()...),
+			Steps:   w.Tools(),
+		})
+		loaded = append(loaded, openai.ChatCompletionFunctionTool(openai.FunctionDefinitionParam{
+			Name:        w.Name,
+			Description: openai.String(w.Description + " (runs " + strings.Join(w.Tools(), ", ") + " in one call)"),
+			Parameters:  openai.FunctionParameters(w.Parameters),
+		}))
+		registered[w.Name] = w
+	}
+	if len(registered) > 0 {
+		setServices(append(Services(), workflowService))
+	}
+	return loaded
+}
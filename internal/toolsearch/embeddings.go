@@ -0,0 +1,65 @@
+package toolsearch
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Embedder turns texts into vectors for semantic ranking. Implementations must return
+// one vector per text, in order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// OpenAIEmbedder embeds texts with an OpenAI-compatible embeddings endpoint
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIEmbedder(client *openai.Client, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{client: client, model: model}
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	response, err := e.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Model: e.model,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding model %s returned %d vectors for %d texts", e.model, len(response.Data), len(texts))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, data := range response.Data {
+		if data.Index < 0 || int(data.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding model %s returned index %d for %d texts", e.model, data.Index, len(texts))
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	return vectors, nil
+}
+
+// cosine returns the cosine similarity of two vectors, or 0 if either is empty or their
+// lengths differ
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,132 @@
+// Package toolsearch picks the tools worth sending to the model for a query. Tools are
+// ranked with BM25 over their names, summaries, descriptions, tags and parameters,
+// optionally fused with embedding similarity from a pluggable provider, and only the top
+// ranked tools plus any pinned ones are offered.
+package toolsearch
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Document is the searchable text of a tool
+type Document struct {
+	Name string
+	Text string
+}
+
+// Index ranks documents against a query with BM25
+type Index struct {
+	docs   []Document
+	terms  []map[string]int
+	lens   []int
+	avgLen float64
+	df     map[string]int
+}
+
+// Scored is a document name and its relevance to a query
+type Scored struct {
+	Name  string
+	Score float64
+}
+
+// NewIndex tokenizes and indexes documents
+func NewIndex(docs []Document) *Index {
+	idx := &Index{docs: docs, df: map[string]int{}}
+	total := 0
+	for _, doc := range docs {
+		terms := map[string]int{}
+		tokens := Tokenize(doc.Name + " " + doc.Text)
+		for _, token := range tokens {
+			terms[token]++
+		}
+		for term := range terms {
+			idx.df[term]++
+		}
+		idx.terms = append(idx.terms, terms)
+		idx.lens = append(idx.lens, len(tokens))
+		total += len(tokens)
+	}
+	if len(docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(docs))
+	}
+	return idx
+}
+
+// Rank scores every document against the query, best first. Ties keep index order.
+func (idx *Index) Rank(query string) []Scored {
+	queryTerms := Tokenize(query)
+	n := float64(len(idx.docs))
+
+	ranked := make([]Scored, len(idx.docs))
+	for i, doc := range idx.docs {
+		score := 0.0
+		for _, term := range queryTerms {
+			tf := float64(idx.terms[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - b + b*float64(idx.lens[i])/idx.avgLen
+			score += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+		ranked[i] = Scored{Name: doc.Name, Score: score}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+// stopwords are too common in queries and API descriptions to tell tools apart
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "get": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "me": true, "of": true, "on": true, "or": true, "the": true,
+	"this": true, "to": true, "what": true, "whats": true, "when": true, "which": true,
+	"who": true, "with": true, "show": true, "list": true, "give": true, "tell": true,
+}
+
+// Tokenize lowercases text, splits it on punctuation, underscores and camelCase, and
+// drops stopwords and a plural "s" so "getOddsChanges" matches "odds change"
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		token := strings.ToLower(string(word))
+		word = word[:0]
+		if stopwords[token] {
+			return
+		}
+		if len(token) > 3 && strings.HasSuffix(token, "s") && !strings.HasSuffix(token, "ss") {
+			token = token[:len(token)-1]
+		}
+		tokens = append(tokens, token)
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// Split camelCase at a lower-to-upper boundary
+			if unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]) {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
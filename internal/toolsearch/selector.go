@@ -0,0 +1,189 @@
+package toolsearch
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"sportsagent/internal/config"
+	"sportsagent/internal/tools"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// rrfK damps the weight of top ranks when fusing keyword and embedding rankings
+const rrfK = 60
+
+// Selector chooses the tools offered for a query
+type Selector struct {
+	maxTools int
+	pinned   []string
+	docs     []Document
+	index    *Index
+	embedder Embedder
+
+	mu sync.Mutex
+	// vectors are the document embeddings, computed on first use
+	vectors [][]float64
+}
+
+// New indexes docs for selection. A nil embedder ranks by keywords alone.
+func New(cfg config.ToolSelectionConfig, docs []Document, embedder Embedder) *Selector {
+	return &Selector{
+		maxTools: cfg.MaxTools,
+		pinned:   cfg.Pinned,
+		docs:     docs,
+		index:    NewIndex(docs),
+		embedder: embedder,
+	}
+}
+
+// Documents builds the searchable text of each function tool from its name, description,
+// parameters and the summary, description and tags of its operation
+func Documents(defs []openai.ChatCompletionToolUnionParam) []Document {
+	var docs []Document
+	for _, def := range defs {
+		fn := def.GetFunction()
+		if fn == nil {
+			continue
+		}
+		parts := []string{fn.Description.Value}
+		if metadata, ok := tools.GetToolMetadata(fn.Name); ok {
+			parts = append(parts, metadata.Service, metadata.Summary, metadata.Description)
+			parts = append(parts, metadata.Tags...)
+		}
+		if properties, ok := fn.Parameters["properties"].(map[string]any); ok {
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				parts = append(parts, name)
+				if schema, ok := properties[name].(map[string]any); ok {
+					if description, ok := schema["description"].(string); ok {
+						parts = append(parts, description)
+					}
+				}
+			}
+		}
+		docs = append(docs, Document{Name: fn.Name, Text: strings.Join(parts, " ")})
+	}
+	return docs
+}
+
+// Select returns the candidates to offer for the query: the pinned ones plus the
+// MaxTools best ranked. It returns nil, meaning every candidate, when selection is
+// disabled or the candidates already fit.
+func (s *Selector) Select(ctx context.Context, query string, candidates []string) []string {
+	if s.maxTools == 0 || len(candidates) <= s.maxTools {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(candidates))
+	var selected []string
+	for _, name := range candidates {
+		if s.isPinned(name) {
+			selected = append(selected, name)
+		} else {
+			allowed[name] = true
+		}
+	}
+
+	ranked := s.index.Rank(query)
+	if s.embedder != nil {
+		if similar, err := s.similar(ctx, query); err != nil {
+			slog.WarnContext(ctx, "tool embeddings failed, ranking by keywords only", "error", err)
+		} else {
+			ranked = fuse(ranked, similar)
+		}
+	}
+
+	picked := 0
+	for _, scored := range ranked {
+		if picked == s.maxTools {
+			break
+		}
+		if allowed[scored.Name] {
+			selected = append(selected, scored.Name)
+			picked++
+		}
+	}
+	return selected
+}
+
+func (s *Selector) isPinned(name string) bool {
+	for _, pattern := range s.pinned {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// similar ranks the documents by embedding similarity to the query
+func (s *Selector) similar(ctx context.Context, query string) ([]Scored, error) {
+	vectors, err := s.documentVectors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	embedded, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]Scored, len(s.docs))
+	for i, doc := range s.docs {
+		ranked[i] = Scored{Name: doc.Name, Score: cosine(embedded[0], vectors[i])}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked, nil
+}
+
+// documentVectors embeds the documents once. A failure is retried on the next query.
+func (s *Selector) documentVectors(ctx context.Context) ([][]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vectors != nil {
+		return s.vectors, nil
+	}
+	texts := make([]string, len(s.docs))
+	for i, doc := range s.docs {
+		texts[i] = doc.Name + ": " + doc.Text
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	s.vectors = vectors
+	return vectors, nil
+}
+
+// fuse combines rankings with reciprocal rank fusion, so neither score scale dominates.
+// Documents that did not match in a ranking get nothing from it.
+func fuse(rankings ...[]Scored) []Scored {
+	scores := map[string]float64{}
+	var order []string
+	for _, ranking := range rankings {
+		for rank, scored := range ranking {
+			if _, seen := scores[scored.Name]; !seen {
+				order = append(order, scored.Name)
+				scores[scored.Name] = 0
+			}
+			if scored.Score > 0 {
+				scores[scored.Name] += 1.0 / float64(rrfK+rank+1)
+			}
+		}
+	}
+
+	fused := make([]Scored, len(order))
+	for i, name := range order {
+		fused[i] = Scored{Name: name, Score: scores[name]}
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	return fused
+}
@@ -0,0 +1,89 @@
+package toolsearch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"sportsagent/internal/config"
+)
+
+var catalogue = []Document{
+	{Name: "get_roto_data", Text: "Get the latest sports news feed from rotoreader news injuries"},
+	{Name: "get_odds_data", Text: "Get recent betting odds changes from oddstracker odds lines"},
+	{Name: "getPlayerStats", Text: "Season statistics for a player"},
+	{Name: "create_alert", Text: "Create an alert when a line moves"},
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("getOddsChanges for the Chiefs_line")
+	if want := []string{"odd", "change", "chief", "line"}; !slices.Equal(got, want) {
+		t.Fatalf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestIndexRank(t *testing.T) {
+	ranked := NewIndex(catalogue).Rank("What are the odds on KC?")
+	if ranked[0].Name != "get_odds_data" || ranked[0].Score <= 0 {
+		t.Fatalf("expected get_odds_data first, got %+v", ranked)
+	}
+	if ranked[len(ranked)-1].Score != 0 {
+		t.Fatalf("unrelated tools should score 0, got %+v", ranked)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	names := []string{"get_roto_data", "get_odds_data", "getPlayerStats", "create_alert"}
+	selector := New(config.ToolSelectionConfig{MaxTools: 1, Pinned: []string{"create_*"}}, catalogue, nil)
+
+	got := selector.Select(context.Background(), "any injury news on Mahomes?", names)
+	if want := []string{"create_alert", "get_roto_data"}; !slices.Equal(got, want) {
+		t.Fatalf("Select = %q, want pinned plus top ranked %q", got, want)
+	}
+	if got := selector.Select(context.Background(), "news", names[:1]); got != nil {
+		t.Fatalf("candidates that fit should not be narrowed, got %q", got)
+	}
+	if got := New(config.ToolSelectionConfig{}, catalogue, nil).Select(context.Background(), "news", names); got != nil {
+		t.Fatalf("MaxTools 0 should offer every tool, got %q", got)
+	}
+}
+
+// fakeEmbedder maps each text to a fixed vector, failing when err is set
+type fakeEmbedder struct {
+	vectors map[string][]float64
+	err     error
+}
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		out[i] = e.vectors[text]
+	}
+	return out, nil
+}
+
+func TestSelectFusesEmbeddings(t *testing.T) {
+	names := []string{"get_roto_data", "get_odds_data", "getPlayerStats", "create_alert"}
+	embedder := &fakeEmbedder{vectors: map[string][]float64{
+		"how is Mahomes playing this year":     {1, 0},
+		"getPlayerStats: " + catalogue[2].Text: {1, 0},
+		"get_roto_data: " + catalogue[0].Text:  {0, 1},
+		"get_odds_data: " + catalogue[1].Text:  {0, 1},
+		"create_alert: " + catalogue[3].Text:   {0, 1},
+	}}
+	selector := New(config.ToolSelectionConfig{MaxTools: 1}, catalogue, embedder)
+
+	// No keyword matches, so only the embeddings can find the stats tool
+	if got := selector.Select(context.Background(), "how is Mahomes playing this year", names); !slices.Equal(got, []string{"getPlayerStats"}) {
+		t.Fatalf("expected embeddings to pick getPlayerStats, got %q", got)
+	}
+
+	failing := New(config.ToolSelectionConfig{MaxTools: 1}, catalogue, &fakeEmbedder{err: errors.New("down")})
+	if got := failing.Select(context.Background(), "odds", names); !slices.Equal(got, []string{"get_odds_data"}) {
+		t.Fatalf("expected keyword ranking when embeddings fail, got %q", got)
+	}
+}
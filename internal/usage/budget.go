@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"sportsagent/internal/config"
+)
+
+// ErrBudgetExceeded is wrapped by every *BudgetError
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+const (
+	ScopeRequest = "request"
+	ScopeDaily   = "daily"
+)
+
+// BudgetError reports which limit stopped the agent loop
+type BudgetError struct {
+	Scope string
+	// Limit describes the exceeded limit, e.g. "20000 tokens" or "$0.50"
+	Limit string
+	Used  Usage
+	// RetryAfter is the time until a daily budget resets; zero for request budgets
+	RetryAfter time.Duration
+}
+
+func (e *BudgetError) Error() string {
+	if e.Scope == ScopeDaily {
+		return fmt.Sprintf("daily budget of %s for this API key is exhausted; it resets at midnight UTC", e.Limit)
+	}
+	return fmt.Sprintf("query stopped after exceeding the per-request budget of %s (used %d tokens, $%.4f)",
+		e.Limit, e.Used.TotalTokens, e.Used.CostUSD)
+}
+
+func (e *BudgetError) Unwrap() error { return ErrBudgetExceeded }
+
+// Budget holds the configured limits; zero values are unlimited
+type Budget struct {
+	MaxRequestTokens int64
+	MaxRequestCost   float64
+	DailyTokens      int64
+	DailyCost        float64
+}
+
+func NewBudget(cfg config.UsageConfig) Budget {
+	return Budget{
+		MaxRequestTokens: cfg.MaxRequestTokens,
+		MaxRequestCost:   cfg.MaxRequestCost,
+		DailyTokens:      cfg.DailyTokens,
+		DailyCost:        cfg.DailyCost,
+	}
+}
+
+// CheckRequest returns a *BudgetError once a single query has spent more than allowed
+func (b Budget) CheckRequest(used Usage) error {
+	if limit, ok := exceeded(used, b.MaxRequestTokens, b.MaxRequestCost, false); ok {
+		return &BudgetError{Scope: ScopeRequest, Limit: limit, Used: used}
+	}
+	return nil
+}
+
+// CheckDaily returns a *BudgetError once an API key has used up its daily allowance.
+// It is checked before each completion, so reaching the limit exactly also stops.
+func (b Budget) CheckDaily(spent Usage, now time.Time) error {
+	if limit, ok := exceeded(spent, b.DailyTokens, b.DailyCost, true); ok {
+		return &BudgetError{Scope: ScopeDaily, Limit: limit, Used: spent, RetryAfter: untilMidnightUTC(now)}
+	}
+	return nil
+}
+
+func exceeded(used Usage, maxTokens int64, maxCost float64, inclusive bool) (string, bool) {
+	over := func(v, limit float64) bool {
+		if inclusive {
+			return v >= limit
+		}
+		return v > limit
+	}
+	if maxTokens > 0 && over(float64(used.TotalTokens), float64(maxTokens)) {
+		return fmt.Sprintf("%d tokens", maxTokens), true
+	}
+	if maxCost > 0 && over(used.CostUSD, maxCost) {
+		return fmt.Sprintf("$%.2f", maxCost), true
+	}
+	return "", false
+}
+
+func untilMidnightUTC(now time.Time) time.Duration {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return midnight.Sub(now)
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"sync"
+	"time"
+)
+
+// Ledger aggregates spend per API key for the current UTC day. It is kept in memory,
+// so totals restart with the process and are not shared between replicas.
+type Ledger struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	day  string
+	keys map[string]Usage
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{now: time.Now, keys: map[string]Usage{}}
+}
+
+// Report is a snapshot of the current day's spend
+type Report struct {
+	Day   string           `json:"day"`
+	Total Usage            `json:"total"`
+	Keys  map[string]Usage `json:"keys"`
+}
+
+// Now returns the ledger clock
+func (l *Ledger) Now() time.Time {
+	return l.now()
+}
+
+// Add records spend for key
+func (l *Ledger) Add(key string, u Usage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollover()
+	spent := l.keys[key]
+	spent.Add(u)
+	l.keys[key] = spent
+}
+
+// Spent returns what key has used today
+func (l *Ledger) Spent(key string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollover()
+	return l.keys[key]
+}
+
+// Report returns today's spend per key and in total
+func (l *Ledger) Report() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollover()
+	report := Report{Day: l.day, Keys: make(map[string]Usage, len(l.keys))}
+	for key, u := range l.keys {
+		report.Keys[key] = u
+		report.Total.Add(u)
+	}
+	return report
+}
+
+// rollover discards the previous day's totals; callers hold mu
+func (l *Ledger) rollover() {
+	day := l.now().UTC().Format(time.DateOnly)
+	if day != l.day {
+		l.day = day
+		l.keys = map[string]Usage{}
+	}
+}
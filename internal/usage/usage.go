@@ -0,0 +1,59 @@
+// Package usage converts model token usage to cost and enforces spend budgets.
+package usage
+
+import (
+	"strings"
+
+	"sportsagent/internal/config"
+)
+
+// Usage is the token count and cost of one or more completions
+type Usage struct {
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	CostUSD          float64 `json:"costUsd"`
+}
+
+// Add accumulates other into u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CostUSD += other.CostUSD
+}
+
+// Prices maps model names to USD per million tokens
+type Prices map[string]config.ModelPrice
+
+// Lookup returns the price for a model. Dated snapshots such as gpt-4o-2024-08-06
+// match the longest configured prefix.
+func (p Prices) Lookup(model string) (config.ModelPrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+
+	var best string
+	for name := range p {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return config.ModelPrice{}, false
+	}
+	return p[best], true
+}
+
+// Cost prices a completion. Models without a configured price cost nothing.
+func (p Prices) Cost(model string, promptTokens, completionTokens int64) Usage {
+	u := Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+	if price, ok := p.Lookup(model); ok {
+		u.CostUSD = (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+	}
+	return u
+}
@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sportsagent/internal/config"
+)
+
+func TestPricesLookup(t *testing.T) {
+	prices := Prices{
+		"gpt-4o":      {Prompt: 2.50, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.60},
+	}
+
+	tests := []struct {
+		model  string
+		prompt float64
+		ok     bool
+	}{
+		{"gpt-4o", 2.50, true},
+		{"gpt-4o-2024-08-06", 2.50, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"gpt-4omni", 0, false},
+		{"o3", 0, false},
+	}
+
+	for _, tt := range tests {
+		price, ok := prices.Lookup(tt.model)
+		if ok != tt.ok || price.Prompt != tt.prompt {
+			t.Errorf("Lookup(%q) = %v, %v; want prompt %v, %v", tt.model, price, ok, tt.prompt, tt.ok)
+		}
+	}
+}
+
+func TestPricesCost(t *testing.T) {
+	prices := Prices{"gpt-4o": {Prompt: 2.50, Completion: 10}}
+
+	u := prices.Cost("gpt-4o", 1_000_000, 500_000)
+	if u.TotalTokens != 1_500_000 || u.CostUSD != 7.50 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+	if u := prices.Cost("unpriced", 100, 100); u.CostUSD != 0 || u.TotalTokens != 200 {
+		t.Fatalf("unpriced model should count tokens at no cost, got %+v", u)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(config.UsageConfig{MaxRequestCost: 0.50, DailyTokens: 1000})
+
+	if err := budget.CheckRequest(Usage{CostUSD: 0.50}); err != nil {
+		t.Fatalf("reaching the request budget exactly should pass, got %v", err)
+	}
+	if err := budget.CheckRequest(Usage{CostUSD: 0.51}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected request budget error, got %v", err)
+	}
+
+	now := time.Date(2025, 9, 7, 23, 30, 0, 0, time.UTC)
+	err := budget.CheckDaily(Usage{TotalTokens: 1000}, now)
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.RetryAfter != 30*time.Minute {
+		t.Fatalf("expected daily budget error resetting in 30m, got %v", err)
+	}
+	if err := budget.CheckDaily(Usage{TotalTokens: 999}, now); err != nil {
+		t.Fatalf("expected remaining daily budget, got %v", err)
+	}
+}
+
+func TestLedgerRollsOverAtMidnightUTC(t *testing.T) {
+	now := time.Date(2025, 9, 7, 23, 59, 0, 0, time.UTC)
+	ledger := NewLedger()
+	ledger.now = func() time.Time { return now }
+
+	ledger.Add("key-a", Usage{TotalTokens: 10, CostUSD: 0.01})
+	ledger.Add("key-a", Usage{TotalTokens: 5})
+	ledger.Add("key-b", Usage{TotalTokens: 1})
+
+	report := ledger.Report()
+	if report.Day != "2025-09-07" || report.Keys["key-a"].TotalTokens != 15 || report.Total.TotalTokens != 16 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if spent := ledger.Spent("key-a"); spent.TotalTokens != 0 {
+		t.Fatalf("expected spend to reset on a new day, got %+v", spent)
+	}
+}
@@ -0,0 +1,200 @@
+package workflows
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	// segField selects a key of an object
+	segField segmentKind = iota
+	// segIndex selects an element of an array; negative indexes count from the end
+	segIndex
+	// segWildcard selects every element of an array or value of an object
+	segWildcard
+	// segDescend selects a key, or every value for "*", at any depth
+	segDescend
+)
+
+type segment struct {
+	kind  segmentKind
+	name  string
+	index int
+}
+
+// Path is a compiled JSONPath. The supported subset is the root $, .name, ['name'], [n],
+// [*], .* and ..name, which covers picking fields out of earlier results.
+type Path struct {
+	raw      string
+	segments []segment
+}
+
+// ParsePath compiles a JSONPath expression
+func ParsePath(expr string) (*Path, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+	p := &Path{raw: expr}
+	rest := expr[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			name, remaining := readName(rest[2:])
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q: expected a name after ..", expr)
+			}
+			p.segments = append(p.segments, segment{kind: segDescend, name: name})
+			rest = remaining
+		case strings.HasPrefix(rest, "."):
+			name, remaining := readName(rest[1:])
+			switch name {
+			case "":
+				return nil, fmt.Errorf("JSONPath %q: expected a name after .", expr)
+			case "*":
+				p.segments = append(p.segments, segment{kind: segWildcard})
+			default:
+				p.segments = append(p.segments, segment{kind: segField, name: name})
+			}
+			rest = remaining
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			switch {
+			case inner == "*":
+				p.segments = append(p.segments, segment{kind: segWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				p.segments = append(p.segments, segment{kind: segField, name: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q: unsupported selector [%s]", expr, inner)
+				}
+				p.segments = append(p.segments, segment{kind: segIndex, index: index})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", expr, rest)
+		}
+	}
+	return p, nil
+}
+
+// readName reads a dotted name up to the next . or [
+func readName(s string) (string, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+func (p *Path) String() string {
+	return p.raw
+}
+
+// Multiple reports whether the path can select more than one value
+func (p *Path) Multiple() bool {
+	for _, seg := range p.segments {
+		if seg.kind == segWildcard || seg.kind == segDescend {
+			return true
+		}
+	}
+	return false
+}
+
+// Root returns the first field the path selects from the root, e.g. "steps" for
+// $.steps.news
+func (p *Path) Root() []string {
+	var names []string
+	for _, seg := range p.segments {
+		if seg.kind != segField {
+			break
+		}
+		names = append(names, seg.name)
+	}
+	return names
+}
+
+// Eval returns the values the path selects from doc, in document order
+func (p *Path) Eval(doc any) []any {
+	nodes := []any{doc}
+	for _, seg := range p.segments {
+		var next []any
+		for _, node := range nodes {
+			next = append(next, seg.apply(node)...)
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	return nodes
+}
+
+func (seg segment) apply(node any) []any {
+	switch seg.kind {
+	case segField:
+		if obj, ok := node.(map[string]any); ok {
+			if value, ok := obj[seg.name]; ok {
+				return []any{value}
+			}
+		}
+	case segIndex:
+		if arr, ok := node.([]any); ok {
+			i := seg.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				return []any{arr[i]}
+			}
+		}
+	case segWildcard:
+		return children(node)
+	case segDescend:
+		var out []any
+		var walk func(any)
+		walk = func(n any) {
+			if seg.name == "*" {
+				out = append(out, children(n)...)
+			} else if obj, ok := n.(map[string]any); ok {
+				if value, ok := obj[seg.name]; ok {
+					out = append(out, value)
+				}
+			}
+			for _, child := range children(n) {
+				walk(child)
+			}
+		}
+		walk(node)
+		return out
+	}
+	return nil
+}
+
+// children returns the elements of an array or the values of an object in key order
+func children(node any) []any {
+	switch n := node.(type) {
+	case []any:
+		return n
+	case map[string]any:
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = n[key]
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package workflows
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPathEval(t *testing.T) {
+	var doc any
+	json.Unmarshal([]byte(`{
+		"args": {"team": "KC"},
+		"steps": {"news": {"items": [
+			{"player": "Patrick Mahomes", "tags": {"team": "KC"}},
+			{"player": "Travis Kelce", "tags": {"team": "KC"}},
+			{"headline": "no player"}
+		]}}
+	}`), &doc)
+
+	tests := []struct {
+		path string
+		want []any
+	}{
+		{path: "$.args.team", want: []any{"KC"}},
+		{path: "$['args']['team']", want: []any{"KC"}},
+		{path: "$.steps.news.items[0].player", want: []any{"Patrick Mahomes"}},
+		{path: "$.steps.news.items[-1].headline", want: []any{"no player"}},
+		{path: "$.steps.news.items[*].player", want: []any{"Patrick Mahomes", "Travis Kelce"}},
+		{path: "$.steps.news.items[*].tags.*", want: []any{"KC", "KC"}},
+		{path: "$..player", want: []any{"Patrick Mahomes", "Travis Kelce"}},
+		{path: "$.steps.news.items[5]", want: nil},
+		{path: "$.args.missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParsePath(tt.path)
+			if err != nil {
+				t.Fatalf("ParsePath returned error: %v", err)
+			}
+			if got := path.Eval(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Eval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePath_Invalid(t *testing.T) {
+	for _, expr := range []string{"args.team", "$.", "$..", "$.items[", "$.items[?(@.x)]", "$x"} {
+		if _, err := ParsePath(expr); err == nil {
+			t.Errorf("expected ParsePath(%q) to fail", expr)
+		}
+	}
+}
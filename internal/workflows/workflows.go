@@ -0,0 +1,410 @@
+// Package workflows runs composite tools declared in a file. A workflow is a DAG of steps,
+// each calling an underlying tool with arguments taken from the workflow's own arguments
+// or, by JSONPath, from earlier steps' results. The model sees the workflow as one tool
+// and the whole chain runs server-side in a single call.
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMaxItems caps how many calls a forEach step makes unless the step says otherwise
+const defaultMaxItems = 25
+
+// Workflow is a composite tool
+type Workflow struct {
+	Name        string         `yaml:"name" json:"name"`
+	Description string         `yaml:"description" json:"description"`
+	Parameters  map[string]any `yaml:"parameters" json:"parameters"`
+	Steps       []Step         `yaml:"steps" json:"steps"`
+	// Output lists the steps whose results are returned; empty returns every step
+	Output []string `yaml:"output" json:"output,omitempty"`
+
+	// waves are the indexes of steps whose dependencies complete in earlier waves
+	waves [][]int
+}
+
+// Step calls one tool
+type Step struct {
+	ID   string `yaml:"id" json:"id"`
+	Tool string `yaml:"tool" json:"tool"`
+	// Needs lists steps that must finish first, in addition to those the arguments reference
+	Needs []string `yaml:"needs" json:"needs,omitempty"`
+	// ForEach is a JSONPath; the tool is called once for each distinct value it selects,
+	// available to the arguments as $.item
+	ForEach string `yaml:"forEach" json:"forEach,omitempty"`
+	// MaxItems caps the calls ForEach makes
+	MaxItems int `yaml:"maxItems" json:"maxItems,omitempty"`
+	// Arguments are passed to the tool. Strings starting with $ are JSONPaths into
+	// {"args": ..., "steps": {"<id>": ...}, "item": ...}; $$ escapes a literal $.
+	Arguments map[string]any `yaml:"arguments" json:"arguments,omitempty"`
+
+	forEach *Path
+	deps    []string
+}
+
+// Caller calls an underlying tool and returns its output
+type Caller func(ctx context.Context, tool string, args map[string]any) (string, error)
+
+type file struct {
+	Workflows []*Workflow `yaml:"workflows"`
+}
+
+// Load reads the workflows in path; an empty path has none
+func Load(path string) ([]*Workflow, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return workflows, nil
+}
+
+// Parse reads and validates workflow definitions
+func Parse(data []byte) ([]*Workflow, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, w := range f.Workflows {
+		if w.Name == "" {
+			return nil, errors.New("every workflow needs a name")
+		}
+		if seen[w.Name] {
+			return nil, fmt.Errorf("workflow %s is defined twice", w.Name)
+		}
+		seen[w.Name] = true
+		if err := w.compile(); err != nil {
+			return nil, fmt.Errorf("workflow %s: %w", w.Name, err)
+		}
+	}
+	return f.Workflows, nil
+}
+
+// Tools lists the distinct tools the workflow calls, in step order
+func (w *Workflow) Tools() []string {
+	var names []string
+	for _, step := range w.Steps {
+		if !slices.Contains(names, step.Tool) {
+			names = append(names, step.Tool)
+		}
+	}
+	return names
+}
+
+// compile checks the steps, resolves their dependencies and orders them into waves
+func (w *Workflow) compile() error {
+	if w.Description == "" {
+		return errors.New("description must not be empty")
+	}
+	if len(w.Steps) == 0 {
+		return errors.New("at least one step is needed")
+	}
+	if w.Parameters == nil {
+		w.Parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+
+	index := map[string]int{}
+	for i, step := range w.Steps {
+		if step.ID == "" || step.Tool == "" {
+			return fmt.Errorf("step %d needs an id and a tool", i+1)
+		}
+		if _, dup := index[step.ID]; dup {
+			return fmt.Errorf("step %s is defined twice", step.ID)
+		}
+		index[step.ID] = i
+	}
+
+	for i := range w.Steps {
+		step := &w.Steps[i]
+		deps := append([]string(nil), step.Needs...)
+		if step.ForEach != "" {
+			path, err := ParsePath(step.ForEach)
+			if err != nil {
+				return fmt.Errorf("step %s: forEach: %w", step.ID, err)
+			}
+			step.forEach = path
+			deps = append(deps, stepReference(path)...)
+		}
+		if step.MaxItems == 0 {
+			step.MaxItems = defaultMaxItems
+		}
+		refs, err := references(step.Arguments, step.forEach != nil)
+		if err != nil {
+			return fmt.Errorf("step %s: %w", step.ID, err)
+		}
+		deps = append(deps, refs...)
+
+		for _, dep := range deps {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("step %s depends on unknown step %s", step.ID, dep)
+			}
+			if dep == step.ID {
+				return fmt.Errorf("step %s depends on itself", step.ID)
+			}
+			if !slices.Contains(step.deps, dep) {
+				step.deps = append(step.deps, dep)
+			}
+		}
+	}
+	for _, id := range w.Output {
+		if _, ok := index[id]; !ok {
+			return fmt.Errorf("output names unknown step %s", id)
+		}
+	}
+
+	// Kahn's algorithm, one wave at a time
+	done := map[string]bool{}
+	for len(done) < len(w.Steps) {
+		var wave []int
+		for i, step := range w.Steps {
+			if done[step.ID] {
+				continue
+			}
+			ready := true
+			for _, dep := range step.deps {
+				ready = ready && done[dep]
+			}
+			if ready {
+				wave = append(wave, i)
+			}
+		}
+		if len(wave) == 0 {
+			return errors.New("steps depend on each other in a cycle")
+		}
+		for _, i := range wave {
+			done[w.Steps[i].ID] = true
+		}
+		w.waves = append(w.waves, wave)
+	}
+	return nil
+}
+
+// references compiles the JSONPaths in an argument value and returns the steps they read
+func references(value any, hasItem bool) ([]string, error) {
+	var refs []string
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "$") || strings.HasPrefix(v, "$$") {
+			return nil, nil
+		}
+		path, err := ParsePath(v)
+		if err != nil {
+			return nil, err
+		}
+		switch root := path.Root(); {
+		case len(root) > 0 && root[0] == "item" && !hasItem:
+			return nil, fmt.Errorf("%s: $.item is only set in forEach steps", v)
+		case len(root) > 0 && (root[0] == "args" || root[0] == "item"):
+		default:
+			ref := stepReference(path)
+			if ref == nil {
+				return nil, fmt.Errorf("%s must start with $.args, $.steps.<id> or $.item", v)
+			}
+			refs = append(refs, ref...)
+		}
+	case map[string]any:
+		for _, child := range v {
+			childRefs, err := references(child, hasItem)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, childRefs...)
+		}
+	case []any:
+		for _, child := range v {
+			childRefs, err := references(child, hasItem)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, childRefs...)
+		}
+	}
+	return refs, nil
+}
+
+// stepReference returns the step a $.steps.<id> path reads
+func stepReference(path *Path) []string {
+	if root := path.Root(); len(root) >= 2 && root[0] == "steps" {
+		return []string{root[1]}
+	}
+	return nil
+}
+
+// Run executes the steps, each wave's steps concurrently, and returns the results of the
+// output steps by step id. The first failing step stops the workflow.
+func (w *Workflow) Run(ctx context.Context, args map[string]any, call Caller) (map[string]any, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	results := map[string]any{}
+	var mu sync.Mutex
+
+	for _, wave := range w.waves {
+		ctx, cancel := context.WithCancel(ctx)
+		errs := make([]error, len(wave))
+		var wg sync.WaitGroup
+		for i, stepIndex := range wave {
+			step := w.Steps[stepIndex]
+			mu.Lock()
+			doc := map[string]any{"args": args, "steps": copyMap(results)}
+			mu.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := step.run(ctx, doc, call)
+				if err != nil {
+					errs[i] = fmt.Errorf("step %s (%s): %w", step.ID, step.Tool, err)
+					cancel()
+					return
+				}
+				mu.Lock()
+				results[step.ID] = result
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		cancel()
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(w.Output) == 0 {
+		return results, nil
+	}
+	output := make(map[string]any, len(w.Output))
+	for _, id := range w.Output {
+		output[id] = results[id]
+	}
+	return output, nil
+}
+
+// run calls the step's tool once, or once per forEach item, returning the decoded result
+// or the list of results
+func (s Step) run(ctx context.Context, doc map[string]any, call Caller) (any, error) {
+	if s.forEach == nil {
+		return s.callWith(ctx, doc, call)
+	}
+
+	items := distinct(s.forEach.Eval(doc))
+	if len(items) > s.MaxItems {
+		return nil, fmt.Errorf("forEach selected %d items, more than maxItems %d", len(items), s.MaxItems)
+	}
+	results := make([]any, len(items))
+	for i, item := range items {
+		itemDoc := map[string]any{"args": doc["args"], "steps": doc["steps"], "item": item}
+		result, err := s.callWith(ctx, itemDoc, call)
+		if err != nil {
+			return nil, fmt.Errorf("item %v: %w", item, err)
+		}
+		results[i] = map[string]any{"item": item, "result": result}
+	}
+	return results, nil
+}
+
+func (s Step) callWith(ctx context.Context, doc map[string]any, call Caller) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	args := map[string]any{}
+	for name, value := range s.Arguments {
+		if resolved, ok := resolve(value, doc); ok {
+			args[name] = resolved
+		}
+	}
+	output, err := call(ctx, s.Tool, args)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if json.Unmarshal([]byte(output), &decoded) != nil {
+		return output, nil
+	}
+	return decoded, nil
+}
+
+// resolve replaces JSONPaths in an argument value. A path that selects nothing leaves the
+// argument out; one that can select several values always yields a list.
+func resolve(value any, doc map[string]any) (any, bool) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			return v[1:], true
+		}
+		if !strings.HasPrefix(v, "$") {
+			return v, true
+		}
+		// Paths were checked when the workflow was loaded
+		path, _ := ParsePath(v)
+		values := path.Eval(doc)
+		if path.Multiple() {
+			return values, len(values) > 0
+		}
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, child := range v {
+			if resolved, ok := resolve(child, doc); ok {
+				out[key] = resolved
+			}
+		}
+		return out, true
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, child := range v {
+			if resolved, ok := resolve(child, doc); ok {
+				out = append(out, resolved)
+			}
+		}
+		return out, true
+	default:
+		return value, true
+	}
+}
+
+// distinct drops repeated values, keeping the first of each, and nulls
+func distinct(values []any) []any {
+	seen := map[string]bool{}
+	var out []any
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		key, _ := json.Marshal(value)
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		out = append(out, value)
+	}
+	return out
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const newsWithOdds = `
+workflows:
+  - name: news_with_odds
+    description: Latest news for a team with the odds changes for the players it mentions
+    parameters:
+      type: object
+      properties:
+        team: {type: string}
+      required: [team]
+    steps:
+      - id: news
+        tool: get_news
+        arguments:
+          team: $.args.team
+          limit: 10
+      - id: odds
+        tool: get_odds
+        forEach: $.steps.news.items[*].player
+        arguments:
+          player: $.item
+          market: $$spread
+      - id: lines
+        tool: get_lines
+        arguments:
+          team: $.args.team
+    output: [news, odds]
+`
+
+// fakeCaller records calls and answers each tool from canned JSON
+type fakeCaller struct {
+	mu      sync.Mutex
+	calls   []string
+	answers map[string]func(args map[string]any) (string, error)
+}
+
+func (f *fakeCaller) call(ctx context.Context, tool string, args map[string]any) (string, error) {
+	data, _ := json.Marshal(args)
+	f.mu.Lock()
+	f.calls = append(f.calls, tool+" "+string(data))
+	f.mu.Unlock()
+	return f.answers[tool](args)
+}
+
+func TestRun(t *testing.T) {
+	workflows, err := Parse([]byte(newsWithOdds))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	w := workflows[0]
+	if got := w.Tools(); !reflect.DeepEqual(got, []string{"get_news", "get_odds", "get_lines"}) {
+		t.Fatalf("Tools = %v", got)
+	}
+
+	caller := &fakeCaller{answers: map[string]func(map[string]any) (string, error){
+		"get_news": func(map[string]any) (string, error) {
+			return `{"items":[{"player":"Patrick Mahomes"},{"player":"Travis Kelce"},{"player":"Patrick Mahomes"},{"headline":"bye week"}]}`, nil
+		},
+		"get_odds": func(args map[string]any) (string, error) {
+			return fmt.Sprintf(`{"player":%q,"line":-3.5}`, args["player"]), nil
+		},
+		"get_lines": func(map[string]any) (string, error) { return "not json", nil },
+	}}
+
+	results, err := w.Run(context.Background(), map[string]any{"team": "KC"}, caller.call)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	// news and lines run in the first wave, then one odds call per distinct player
+	if len(caller.calls) != 4 {
+		t.Fatalf("expected 4 calls, got %v", caller.calls)
+	}
+	for _, want := range []string{
+		`get_news {"limit":10,"team":"KC"}`,
+		`get_lines {"team":"KC"}`,
+		`get_odds {"market":"$spread","player":"Patrick Mahomes"}`,
+		`get_odds {"market":"$spread","player":"Travis Kelce"}`,
+	} {
+		found := false
+		for _, call := range caller.calls {
+			found = found || call == want
+		}
+		if !found {
+			t.Errorf("expected call %s, got %v", want, caller.calls)
+		}
+	}
+	if !strings.HasPrefix(caller.calls[2], "get_odds") {
+		t.Errorf("odds must wait for the news, got %v", caller.calls)
+	}
+
+	if _, ok := results["lines"]; ok || len(results) != 2 {
+		t.Fatalf("only the output steps should be returned, got %v", results)
+	}
+	odds := results["odds"].([]any)
+	if len(odds) != 2 || odds[1].(map[string]any)["item"] != "Travis Kelce" {
+		t.Fatalf("unexpected odds results %v", odds)
+	}
+}
+
+func TestRun_StepFailureStopsWorkflow(t *testing.T) {
+	workflows, _ := Parse([]byte(newsWithOdds))
+	backendDown := errors.New("backend down")
+	caller := &fakeCaller{answers: map[string]func(map[string]any) (string, error){
+		"get_news":  func(map[string]any) (string, error) { return "", backendDown },
+		"get_lines": func(map[string]any) (string, error) { return "{}", nil },
+	}}
+
+	_, err := workflows[0].Run(context.Background(), map[string]any{"team": "KC"}, caller.call)
+	if !errors.Is(err, backendDown) || !strings.Contains(err.Error(), "step news (get_news)") {
+		t.Fatalf("expected the news step's error, got %v", err)
+	}
+	for _, call := range caller.calls {
+		if strings.HasPrefix(call, "get_odds") {
+			t.Fatal("steps after a failure must not run")
+		}
+	}
+}
+
+func TestRun_ForEachLimit(t *testing.T) {
+	workflows, err := Parse([]byte(`
+workflows:
+  - name: every_player
+    description: Odds for every player in the feed
+    steps:
+      - {id: news, tool: get_news}
+      - {id: odds, tool: get_odds, forEach: "$.steps.news[*]", maxItems: 2, arguments: {player: $.item}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caller := &fakeCaller{answers: map[string]func(map[string]any) (string, error){
+		"get_news": func(map[string]any) (string, error) { return `["a","b","c"]`, nil },
+	}}
+	if _, err := workflows[0].Run(context.Background(), nil, caller.call); err == nil || !strings.Contains(err.Error(), "maxItems") {
+		t.Fatalf("expected the forEach limit to fail the workflow, got %v", err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no description": `{id: a, tool: t}`,
+		"unknown step":   `{id: a, tool: t, arguments: {x: $.steps.b.y}}`,
+		"bad path":       `{id: a, tool: t, arguments: {x: "$.items["}}`,
+		"bad root":       `{id: a, tool: t, arguments: {x: $.results}}`,
+		"item outside":   `{id: a, tool: t, arguments: {x: $.item}}`,
+		"cycle":          `{id: a, tool: t, needs: [b]}, {id: b, tool: t, arguments: {x: $.steps.a}}`,
+		"duplicate step": `{id: a, tool: t}, {id: a, tool: u}`,
+	}
+
+	for name, steps := range tests {
+		t.Run(name, func(t *testing.T) {
+			description := "description: d, "
+			if name == "no description" {
+				description = ""
+			}
+			data := fmt.Sprintf("workflows:\n  - {name: w, %ssteps: [%s]}\n", description, steps)
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Fatalf("expected an error for\n%s", data)
+			}
+		})
+	}
+}
@@ -1,76 +1,163 @@
 package main
 
 import (
-	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"sportsagent/internal/auth"
+	"sportsagent/internal/config"
 	"sportsagent/internal/handlers"
+	"sportsagent/internal/health"
+	"sportsagent/internal/logging"
+	"sportsagent/internal/services"
+	"sportsagent/internal/tools"
 	"sportsagent/internal/version"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
-func setupServer() *http.ServeMux {
-	mux := http.NewServeMux()
-	handler := handlers.NewAgentHandler()
-	toolsHandler := handlers.NewToolsHandler()
-	mux.Handle("/query", otelhttp.NewHandler(http.HandlerFunc(handler.HandleQuery), "Query"))
-	mux.Handle("/tools", otelhttp.NewHandler(http.HandlerFunc(toolsHandler.HandleGetTools), "Tools"))
-	mux.HandleFunc("/healthz", handlers.HandleHealth)
-	mux.Handle("/metrics", promhttp.Handler())
-	return mux
-}
+const usage = `Usage: sportsagent <command> [flags] [args]
 
-func main() {
-	godotenv.Load()
+Commands:
+  serve                       start the HTTP server (default)
+  query "<question>"          answer a single question in the terminal
+  chat                        interactive chat with tool call tracing
+  tools list                  list the tools offered to the model
+  tools show <name>           show a tool definition and its routing metadata
+  tools invoke <name> [json]  call a tool directly with JSON arguments
+  spec dump                   print the resolved function schemas as JSON
+  config print                print the effective configuration with secrets redacted
+  auth hash-key [key | -]     hash an API key for auth.apiKeys, generating one if omitted
+  version                     print version information
+
+Run 'sportsagent <command> -h' for command flags.
+`
 
-	shutdown, err := initTracing(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+func setupServer(cfg *config.Config, healthHandler *handlers.HealthHandler) (*http.ServeMux, error) {
+	authenticator, err := auth.NewAuthenticator(cfg.Auth)
 	if err != nil {
-		log.Fatalf("setup tracing: %v", err)
+		return nil, fmt.Errorf("setup authentication: %w", err)
+	}
+	if !authenticator.Enabled() {
+		slog.Warn("authentication disabled: no API keys or JWKS file configured (auth.apiKeys, auth.jwt.jwksFile)")
+	}
+	limiter := auth.NewRateLimiter(cfg.Auth.RateLimit)
+	// protect authenticates and rate limits API routes; the span wraps it so rejected
+	// requests are traced as well
+	protect := func(h http.HandlerFunc, operation string) http.Handler {
+		return otelhttp.NewHandler(auth.Middleware(authenticator, limiter, h), operation)
 	}
-	defer shutdown(context.Background())
 
-	mux := setupServer()
+	mux := http.NewServeMux()
+	agentService := services.NewAgentService(cfg)
+	handler := handlers.NewAgentHandler(agentService)
+	toolsHandler := handlers.NewToolsHandler(agentService)
+	mux.Handle("/query", protect(handler.HandleQuery, "Query"))
+	mux.Handle("/approvals/{id}", protect(handler.HandleApproval, "Approval"))
+	mux.Handle("/sources/{id}", protect(handler.HandleSource, "Source"))
+	mux.Handle("/tools", protect(toolsHandler.HandleGetTools, "Tools"))
+	mux.Handle("/tools/{name}/invoke", protect(toolsHandler.HandleInvokeTool, "InvokeTool"))
+	if cfg.Server.AdminToken != "" {
+		adminHandler := handlers.NewAdminHandler(agentService, cfg.Server.AdminToken)
+		mux.Handle("/admin/usage", otelhttp.NewHandler(http.HandlerFunc(adminHandler.HandleUsage), "AdminUsage"))
+		mux.Handle("/admin/log-level", otelhttp.NewHandler(http.HandlerFunc(adminHandler.HandleLogLevel), "AdminLogLevel"))
+	}
+	mux.HandleFunc("/livez", healthHandler.HandleLivez)
+	mux.HandleFunc("/readyz", healthHandler.HandleReadyz)
+	// /healthz predates the livez/readyz split and is kept as a liveness alias
+	mux.HandleFunc("/healthz", healthHandler.HandleLivez)
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux, nil
+}
 
-	log.Println("Starting GoSportsAgent version:", version.Version, "server on :8082")
-	if err := http.ListenAndServe(":8082", mux); err != nil {
-		log.Fatal(err)
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
 
-func initTracing(endpoint string) (func(context.Context) error, error) {
-	exp, err := otlptracehttp.New(context.Background(),
-		otlptracehttp.WithEndpointURL(endpoint),
-		otlptracehttp.WithInsecure(),
-	)
+// loadConfig resolves the configuration and installs the logger it describes
+func loadConfig(flags *config.Flags) (*config.Config, error) {
+	cfg, err := flags.Load()
 	if err != nil {
 		return nil, err
 	}
+	if err := logging.Setup(os.Stderr, cfg.Logging); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName("go-sportsagent"),
-		)),
-	)
+// newReadinessChecker builds the dependency checks behind /readyz
+func newReadinessChecker(cfg *config.Config) *health.Checker {
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
 
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(
-		propagation.NewCompositeTextMapPropagator(
-			propagation.TraceContext{},
-			propagation.Baggage{},
-		),
+	return health.NewChecker(cfg.Health.CacheTTL, cfg.Health.Timeout,
+		health.HTTPCheck(tools.ServiceRotoReader, strings.TrimRight(cfg.Services.RotoReaderURL, "/")+cfg.Health.RotoReaderProbePath, client),
+		health.HTTPCheck(tools.ServiceOddsTracker, strings.TrimRight(cfg.Services.OddsTrackerURL, "/")+cfg.Health.OddsTrackerProbePath, client),
+		health.ToolSourceCheck(),
+		health.CredentialCheck("llm", cfg.OpenAI.APIKey),
 	)
+}
+
+// run dispatches to the subcommand named by the first argument, defaulting to serve
+func run(args []string) error {
+	godotenv.Load()
 
-	return tp.Shutdown, nil
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		return runServe(args)
+	}
+
+	switch args[0] {
+	case "serve":
+		return runServe(args[1:])
+	case "query":
+		return runQuery(args[1:])
+	case "chat":
+		return runChat(args[1:])
+	case "tools":
+		return runTools(args[1:])
+	case "spec":
+		return runSpec(args[1:])
+	case "config":
+		return runConfig(args[1:])
+	case "auth":
+		return runAuth(args[1:])
+	case "version":
+		fmt.Printf("sportsagent %s (commit %s, built %s)\n", version.BuildVersion, version.Commit, version.Date)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// parseArgs parses flags that may appear before, between or after positional arguments
+// and returns the positional arguments in order
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sportsagent/internal/handlers"
+)
+
+func startTestServer(t *testing.T, handler http.Handler, drainTimeout time.Duration) (string, *handlers.HealthHandler, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	health := handlers.NewHealthHandler(nil)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveUntilDone(ctx, &http.Server{Handler: handler}, ln, health, drainTimeout)
+	}()
+
+	return "http://" + ln.Addr().String(), health, cancel, done
+}
+
+func TestServeUntilDoneDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	url, health, cancel, done := startTestServer(t, handler, 5*time.Second)
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	if got := <-result; got != "done" {
+		t.Fatalf("in-flight request was not drained, got %q", got)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("serveUntilDone returned error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	health.HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected readiness to fail after shutdown, got %d", w.Code)
+	}
+}
+
+func TestServeUntilDoneCancelsRequestsAfterDeadline(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	})
+
+	url, _, cancel, done := startTestServer(t, handler, 50*time.Millisecond)
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("request context was not cancelled after the drain deadline")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("serveUntilDone returned error: %v", err)
+	}
+}